@@ -0,0 +1,142 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize represents a size in bytes that is parsed from and formatted as a human-readable string.
+//
+// Both SI (decimal, e.g., "1.5GB") and IEC (binary, e.g., "512Mi" or "512MiB") units are supported.
+type ByteSize int64
+
+// Common byte sizes in SI (decimal) and IEC (binary) units.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+	EiB ByteSize = 1024 * PiB
+)
+
+// byteSizeUnits maps the accepted (lowercase) unit suffixes to their sizes.
+var byteSizeUnits = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "ki": KiB, "kib": KiB,
+	"m": MB, "mb": MB, "mi": MiB, "mib": MiB,
+	"g": GB, "gb": GB, "gi": GiB, "gib": GiB,
+	"t": TB, "tb": TB, "ti": TiB, "tib": TiB,
+	"p": PB, "pb": PB, "pi": PiB, "pib": PiB,
+	"e": EB, "eb": EB, "ei": EiB, "eib": EiB,
+}
+
+// byteSizeFormats lists the units used by String, from largest to smallest.
+var byteSizeFormats = []struct {
+	name string
+	size ByteSize
+}{
+	{"Ei", EiB}, {"EB", EB},
+	{"Pi", PiB}, {"PB", PB},
+	{"Ti", TiB}, {"TB", TB},
+	{"Gi", GiB}, {"GB", GB},
+	{"Mi", MiB}, {"MB", MB},
+	{"Ki", KiB}, {"KB", KB},
+}
+
+// ParseByteSize parses a human-readable size such as "512Mi", "1.5GB", "10MB", "512KiB" or "512k" into bytes.
+// Units are case-insensitive; a number without a unit is a number of bytes. A leading sign is accepted, so
+// negative sizes formatted by String (e.g., "-2Gi") are read back.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	sign, digits := ByteSize(1), s
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = -1, digits[1:]
+	} else {
+		digits = strings.TrimPrefix(digits, "+")
+	}
+	i := strings.IndexFunc(digits, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(digits)
+	}
+	num, unit := digits[:i], strings.ToLower(strings.TrimSpace(digits[i:]))
+	if num == "" {
+		return 0, fmt.Errorf("invalid byte size: %q", s)
+	}
+
+	size, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size unit: %q", s)
+	}
+
+	// Prefer exact integer arithmetic, fall back to floats for fractional values
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/int64(size) {
+			return 0, fmt.Errorf("byte size out of range: %q", s)
+		}
+		return sign * ByteSize(n) * size, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %q", s)
+	}
+	bytes := math.Round(f * float64(size))
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size out of range: %q", s)
+	}
+	return sign * ByteSize(bytes), nil
+}
+
+// String formats the size using the unit that gives the shortest exact representation, so that
+// parsing the result yields the same size (e.g., "512Mi", "1.5GB").
+func (b ByteSize) String() string {
+	sign, u := "", uint64(b)
+	if b < 0 {
+		sign, u = "-", uint64(-b)
+	}
+
+	best := strconv.FormatUint(u, 10) + "B"
+	for _, f := range byteSizeFormats {
+		size := uint64(f.size)
+		rem := u % size
+		// Only consider units that represent the size exactly with up to three decimals
+		if u < size || rem > math.MaxUint64/1000 || rem*1000%size != 0 {
+			continue
+		}
+		s := strconv.FormatUint(u/size, 10)
+		if rem != 0 {
+			s = strings.TrimRight(fmt.Sprintf("%s.%03d", s, rem*1000/size), "0")
+		}
+		if s += f.name; len(s) < len(best) {
+			best = s
+		}
+	}
+	return sign + best
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	v, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
@@ -0,0 +1,80 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/igwtcode/go-env"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]env.ByteSize{
+		"0":      0,
+		"100":    100,
+		"100B":   100,
+		"512k":   512 * env.KB,
+		"10MB":   10 * env.MB,
+		"1.5GB":  1500 * env.MB,
+		"512Mi":  512 * env.MiB,
+		"512KiB": 512 * env.KiB,
+		"1 gib":  env.GiB,
+		"0.5Ki":  512,
+		"-1MB":   -env.MB,
+		"+2Ki":   2 * env.KiB,
+		"-1536B": -1536,
+	}
+
+	for s, expected := range cases {
+		got, err := env.ParseByteSize(s)
+		if err != nil {
+			t.Errorf("expected no error for %q, got %v", s, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("expected %q to be %d, got %d", s, expected, got)
+		}
+	}
+}
+
+func TestParseByteSizeInvalid(t *testing.T) {
+	for _, s := range []string{"", "MB", "-", "--1MB", "+-1MB", "10XB", "1.2.3MB", "9999999EB", "-9999999EB"} {
+		if _, err := env.ParseByteSize(s); err == nil {
+			t.Errorf("expected an error for %q, got none", s)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	cases := map[env.ByteSize]string{
+		0:                "0B",
+		100:              "100B",
+		1024:             "1Ki",
+		1000:             "1KB",
+		512 * env.MiB:    "512Mi",
+		1500 * env.MB:    "1.5GB",
+		1536 * env.MiB:   "1.5Gi",
+		2500 * env.MB:    "2.5GB",
+		-2 * env.GiB:     "-2Gi",
+		1234567:          "1234567B",
+		10 * env.TB:      "10TB",
+		3 * env.EiB:      "3Ei",
+		env.ByteSize(17): "17B",
+	}
+
+	for size, expected := range cases {
+		if got := size.String(); got != expected {
+			t.Errorf("expected %d to format as %q, got %q", int64(size), expected, got)
+		}
+	}
+}
+
+func TestByteSizeRoundTrip(t *testing.T) {
+	for _, s := range []string{"512Mi", "1.5GB", "10MB", "3Ki", "1.25TB", "7B", "-2Ki", "-1.5GB", "-1536B"} {
+		size, err := env.ParseByteSize(s)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", s, err)
+		}
+		if size.String() != s {
+			t.Errorf("expected %q to round-trip, got %q", s, size.String())
+		}
+	}
+}
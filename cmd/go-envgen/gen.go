@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// supportedOptions lists the tag options the generated code implements. Other options fail the generation,
+// and the struct must be populated with the runtime parser instead.
+var supportedOptions = []string{
+	topt.NAME, topt.DEFAULT, topt.REQUIRED, topt.OPTIONAL, topt.NOTRIM, topt.LOWER, topt.UPPER, topt.EXACT,
+	topt.MIN, topt.MAX, topt.GT, topt.GTE, topt.LT, topt.LTE, topt.ONEOF, topt.PREFIX, topt.FLATTEN,
+}
+
+// bounds maps the comparison options to the operator failing the check and the error message of the runtime parser.
+var bounds = []struct {
+	opt  string
+	fail string
+	msg  string
+}{
+	{topt.MIN, "<", "value %v is less than minimum allowed %s"},
+	{topt.MAX, ">", "value %v is greater than maximum allowed %s"},
+	{topt.GT, "<=", "value %v must be greater than %s"},
+	{topt.GTE, "<", "value %v must be greater than or equal to %s"},
+	{topt.LT, ">=", "value %v must be less than %s"},
+	{topt.LTE, ">", "value %v must be less than or equal to %s"},
+}
+
+// config holds the parser settings the generated code is specialized for.
+type config struct {
+	TagOptionSeparator  string
+	SliceValueSeparator string
+	NamePrefix          string
+}
+
+// generator emits UnmarshalEnv methods for the struct types of a package.
+type generator struct {
+	cfg     config
+	pkg     string
+	structs map[string]*ast.StructType // Struct types declared in the package by name
+	texts   map[string]bool            // Types of the package with an UnmarshalText method, decoded as a whole
+	valid   map[string]bool            // Types of the package declaring a Validate method, called once populated
+	imports map[string]bool            // Packages used by the generated code
+	buf     bytes.Buffer
+	vbuf    bytes.Buffer // Calls of the Validate methods, emitted after all fields are populated
+}
+
+// loadPackage parses the non-test Go files of the directory, skipping previously generated files.
+func loadPackage(dir string, cfg config) (*generator, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{cfg: cfg, structs: map[string]*ast.StructType{}, texts: map[string]bool{}, valid: map[string]bool{}, imports: map[string]bool{}}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || strings.HasSuffix(file, "_envgen.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		g.pkg = f.Name.Name
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				if st, ok := n.Type.(*ast.StructType); ok {
+					g.structs[n.Name.Name] = st
+				}
+			case *ast.FuncDecl:
+				if n.Recv == nil || len(n.Recv.List) != 1 {
+					break
+				}
+				recv := strings.TrimPrefix(exprString(n.Recv.List[0].Type), "*")
+				switch n.Name.Name {
+				case "UnmarshalText":
+					g.texts[recv] = true
+				case "Validate":
+					g.valid[recv] = true
+				}
+			}
+			return true
+		})
+	}
+	if g.pkg == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return g, nil
+}
+
+// generate returns the formatted source of the UnmarshalEnv methods of the given struct types.
+func (g *generator) generate(typeNames []string) ([]byte, error) {
+	var body bytes.Buffer
+	for _, name := range typeNames {
+		st, ok := g.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("struct type %s not found in package %s", name, g.pkg)
+		}
+		g.buf.Reset()
+		g.vbuf.Reset()
+		if err := g.writeStruct(st, name, "c", name, "", true); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&body, "\n// UnmarshalEnv populates the struct from environment variables without reflection, like\n")
+		fmt.Fprintf(&body, "// env.Parser.Unmarshal with the settings the code was generated for.\n")
+		fmt.Fprintf(&body, "func (c *%s) UnmarshalEnv() error {\n", name)
+		body.WriteString(`	// lookup returns the name and value of the first variable set to a non-empty value
+	lookup := func(names ...string) (string, string) {
+		for _, name := range names {
+			if val := os.Getenv(name); val != "" {
+				return name, val
+			}
+		}
+		return "", ""
+	}
+	// fail describes the field and the variable in errors, like the runtime parser
+	fail := func(field, name string, err error) error {
+		if name != "" {
+			field += " (" + name + ")"
+		}
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	var name, val string
+	_, _ = name, val
+`)
+		body.Write(g.buf.Bytes())
+		if g.vbuf.Len() == 0 {
+			body.WriteString("\treturn nil\n}\n")
+			continue
+		}
+		// Like the runtime parser, call the Validate methods once all fields are populated, innermost first
+		g.use("errors")
+		body.WriteString("\n\tvar errs []error\n")
+		body.Write(g.vbuf.Bytes())
+		body.WriteString("\treturn errors.Join(errs...)\n}\n")
+	}
+	g.imports["fmt"], g.imports["os"] = true, true
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by go-envgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", g.pkg)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// writeStruct emits the code populating the fields of a struct, accessed through the expression expr, and, when
+// self is set, the call of the Validate method of the named type. Like the runtime parser, embedded structs are
+// validated through the method set of the struct embedding them.
+func (g *generator) writeStruct(st *ast.StructType, typeName string, expr string, path string, prefix string, self bool) error {
+	for _, field := range st.Fields.List {
+		var tagVal string
+		var tagOk bool
+		if field.Tag != nil {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tagVal, tagOk = reflect.StructTag(tag).Lookup("env")
+		}
+		if tagVal == "-" {
+			continue
+		}
+		var opts map[string]string
+		if tagOk {
+			opts = g.parseTag(tagVal)
+		}
+
+		fieldNames := field.Names
+		if len(fieldNames) == 0 {
+			// Embedded field, named after its type
+			ident, ok := field.Type.(*ast.Ident)
+			if !ok {
+				return fmt.Errorf("%s: unsupported embedded field type", path)
+			}
+			fieldNames = []*ast.Ident{ident}
+		}
+
+		for _, ident := range fieldNames {
+			if !ident.IsExported() {
+				continue
+			}
+			fieldExpr, fieldPath := expr+"."+ident.Name, path+"."+ident.Name
+
+			// Recurse into nested and embedded structs, unless they are decoded as a whole like in the runtime parser
+			_, isJSON := opts[topt.JSON]
+			if nested := g.nestedStruct(field.Type); nested != nil && !isJSON && !g.texts[exprString(field.Type)] {
+				nestedPrefix := prefix
+				if _, flatten := opts[topt.FLATTEN]; flatten {
+					nestedPrefix = ""
+				}
+				nestedSelf := len(field.Names) > 0 || !g.hasValidate(typeName)
+				if err := g.writeStruct(nested, exprString(field.Type), fieldExpr, fieldPath, nestedPrefix+opts[topt.PREFIX], nestedSelf); err != nil {
+					return err
+				}
+				continue
+			}
+			if !tagOk {
+				// Structs of other packages would be recursed into by the runtime parser
+				if sel, ok := field.Type.(*ast.SelectorExpr); ok && exprString(sel) != "time.Time" && exprString(sel) != "time.Duration" {
+					return fmt.Errorf("%s: nested type %s of another package is not supported by go-envgen, use the runtime parser", fieldPath, exprString(sel))
+				}
+				continue
+			}
+			if err := g.writeField(ident.Name, field.Type, opts, fieldExpr, fieldPath, prefix); err != nil {
+				return err
+			}
+		}
+	}
+	if self && g.hasValidate(typeName) {
+		fmt.Fprintf(&g.vbuf, "\tif err := %s.Validate(); err != nil {\n\t\terrs = append(errs, fmt.Errorf(\"%%s: %%w\", %q, err))\n\t}\n", expr, path)
+	}
+	return nil
+}
+
+// hasValidate reports whether the method set of a struct type of the package has a Validate method, declared
+// on the type or promoted from a single embedded struct.
+func (g *generator) hasValidate(typeName string) bool {
+	if g.valid[typeName] {
+		return true
+	}
+	st := g.structs[typeName]
+	if st == nil {
+		return false
+	}
+	promoted := 0
+	for _, field := range st.Fields.List {
+		if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 && g.hasValidate(ident.Name) {
+			promoted++
+		}
+	}
+	return promoted == 1
+}
+
+// nestedStruct returns the struct type of a field to recurse into: an inline struct or a struct type of the package.
+func (g *generator) nestedStruct(expr ast.Expr) *ast.StructType {
+	switch t := expr.(type) {
+	case *ast.StructType:
+		return t
+	case *ast.Ident:
+		return g.structs[t.Name]
+	}
+	return nil
+}
+
+// writeField emits the code populating a single field.
+func (g *generator) writeField(fieldName string, typ ast.Expr, opts map[string]string, expr, path, prefix string) error {
+	for opt := range opts {
+		if opt != "" && !slices.Contains(supportedOptions, opt) {
+			return fmt.Errorf("%s: option %q is not supported by go-envgen, use the runtime parser", path, opt)
+		}
+	}
+
+	elem, isSlice := typ, false
+	if arr, ok := typ.(*ast.ArrayType); ok && arr.Len == nil {
+		elem, isSlice = arr.Elt, true
+	}
+	kind, err := g.typeKind(elem)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	names := g.names(fieldName, opts, prefix)
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = strconv.Quote(n)
+	}
+	fmt.Fprintf(&g.buf, "\n\t// %s\n", path)
+	fmt.Fprintf(&g.buf, "\tname, val = lookup(%s)\n", strings.Join(quoted, ", "))
+	g.use("strings")
+	g.buf.WriteString("\tval = strings.TrimRight(strings.TrimPrefix(val, \"\\uFEFF\"), \"\\r\")\n")
+	_, notrim := opts[topt.NOTRIM]
+	if !notrim {
+		g.buf.WriteString("\tval = strings.TrimSpace(val)\n")
+	}
+	if def, ok := opts[topt.DEFAULT]; ok && def != "" {
+		fmt.Fprintf(&g.buf, "\tif val == \"\" {\n\t\tname, val = \"\", %q\n\t}\n", def)
+	}
+	_, required := opts[topt.REQUIRED]
+	if required {
+		msg := fmt.Sprintf("%s: environment variable %s is required but not set", path, strings.Join(names, g.cfg.SliceValueSeparator))
+		g.use("errors")
+		fmt.Fprintf(&g.buf, "\tif val == \"\" {\n\t\treturn errors.New(%q)\n\t}\n", msg)
+	}
+	if _, lower := opts[topt.LOWER]; lower {
+		g.use("strings")
+		g.buf.WriteString("\tval = strings.ToLower(val)\n")
+	}
+	if _, upper := opts[topt.UPPER]; upper {
+		g.use("strings")
+		g.buf.WriteString("\tval = strings.ToUpper(val)\n")
+	}
+
+	goType := exprString(elem)
+	if !isSlice {
+		g.buf.WriteString("\t{\n")
+		if err := g.writeValue("val", kind, goType, opts, required, expr, path); err != nil {
+			return err
+		}
+		g.buf.WriteString("\t}\n")
+		return nil
+	}
+
+	g.use("strings")
+	fmt.Fprintf(&g.buf, "\t{\n\t\telems := make([]%s, 0)\n", goType)
+	fmt.Fprintf(&g.buf, "\tif val != \"\" {\n\t\tfor _, elem := range strings.Split(val, %q) {\n", g.cfg.SliceValueSeparator)
+	if !notrim {
+		g.buf.WriteString("\t\t\telem = strings.TrimSpace(elem)\n\t\t\tif elem == \"\" {\n\t\t\t\tcontinue\n\t\t\t}\n")
+	}
+	if err := g.writeValue("elem", kind, goType, opts, required, "elemVal", path); err != nil {
+		return err
+	}
+	fmt.Fprintf(&g.buf, "\t\t\telems = append(elems, elemVal)\n\t\t}\n\t}\n\t%s = elems\n\t}\n", expr)
+	return nil
+}
+
+// writeValue emits the code validating and converting the string variable src and assigning it to dst.
+// Slice elements are declared as dst.
+func (g *generator) writeValue(src, kind, goType string, opts map[string]string, required bool, dst, path string) error {
+	assign := " = "
+	if dst == "elemVal" {
+		assign = " := "
+	}
+	if allowed, ok := opts[topt.ONEOF]; ok {
+		var list []string
+		for _, a := range strings.Split(allowed, g.cfg.SliceValueSeparator) {
+			list = append(list, strconv.Quote(strings.TrimSpace(a)))
+		}
+		cond := src + ` != ""`
+		if required {
+			cond = "true"
+		}
+		g.use("slices")
+		fmt.Fprintf(&g.buf, "\tif %s && !slices.Contains([]string{%s}, %s) {\n", cond, strings.Join(list, ", "), src)
+		fmt.Fprintf(&g.buf, "\t\treturn fail(%q, name, fmt.Errorf(\"invalid value: %%v. Must be one of %%v\", %s, %q))\n\t}\n",
+			path, src, strings.Join(strings.Split(allowed, g.cfg.SliceValueSeparator), ", "))
+	}
+
+	switch kind {
+	case "string":
+		fmt.Fprintf(&g.buf, "\t%s%s%s\n", dst, assign, convert(goType, "string", src))
+		return nil
+	case "text":
+		for _, b := range bounds {
+			if _, ok := opts[b.opt]; ok {
+				return fmt.Errorf("%s: option %q is not supported by go-envgen for type %s, use the runtime parser", path, b.opt, goType)
+			}
+		}
+		target := "(&" + dst + ")"
+		if dst == "elemVal" {
+			fmt.Fprintf(&g.buf, "\tvar elemVal %s\n", goType)
+			target = dst
+		}
+		fmt.Fprintf(&g.buf, "\terr := %s.UnmarshalText([]byte(%s))\n", target, src)
+		g.writeErrCheck(path)
+		return nil
+	case "bool":
+		g.use("strconv")
+		fmt.Fprintf(&g.buf, "\tb, err := strconv.ParseBool(%s)\n", src)
+		g.writeErrCheck(path)
+		fmt.Fprintf(&g.buf, "\t%s%s%s\n", dst, assign, convert(goType, "bool", "b"))
+		return nil
+	case "duration":
+		g.use("time")
+		fmt.Fprintf(&g.buf, "\td, err := time.ParseDuration(%s)\n", src)
+		g.writeErrCheck(path)
+		fmt.Fprintf(&g.buf, "\t%s%s%s\n", dst, assign, convert(goType, "time.Duration", "d"))
+		return nil
+	}
+
+	g.use("strconv")
+	var parse, parsed string
+	switch kind {
+	case "int":
+		parse, parsed = "strconv.ParseInt("+src+", 10, 64)", "int64"
+	case "uint":
+		parse, parsed = "strconv.ParseUint("+src+", 10, 64)", "uint64"
+	case "float":
+		parse, parsed = "strconv.ParseFloat("+src+", 64)", "float64"
+	}
+	fmt.Fprintf(&g.buf, "\tn, err := %s\n", parse)
+	g.writeErrCheck(path)
+	for _, b := range bounds {
+		bound, ok := opts[b.opt]
+		if !ok {
+			continue
+		}
+		bound = strings.TrimSpace(bound)
+		if err := checkBoundLiteral(kind, bound); err != nil {
+			return fmt.Errorf("%s: invalid %s value: %w", path, b.opt, err)
+		}
+		fmt.Fprintf(&g.buf, "\tif n %s %s {\n\t\treturn fail(%q, name, fmt.Errorf(%q, n, %q))\n\t}\n", b.fail, bound, path, b.msg, bound)
+	}
+	fmt.Fprintf(&g.buf, "\t%s%s%s\n", dst, assign, convert(goType, parsed, "n"))
+	return nil
+}
+
+// convert returns the expression converting v from the parsed type to the field type, if they differ.
+func convert(goType, parsed, v string) string {
+	if goType == parsed {
+		return v
+	}
+	return goType + "(" + v + ")"
+}
+
+// writeErrCheck emits the check of a conversion error.
+func (g *generator) writeErrCheck(path string) {
+	fmt.Fprintf(&g.buf, "\tif err != nil {\n\t\treturn fail(%q, name, err)\n\t}\n", path)
+}
+
+// use records a package imported by the generated code.
+func (g *generator) use(pkg string) {
+	g.imports[pkg] = true
+}
+
+// parseTag parses the tag string into a map of options, like the runtime parser.
+func (g *generator) parseTag(tag string) map[string]string {
+	options := map[string]string{}
+	for _, part := range strings.Split(tag, g.cfg.TagOptionSeparator) {
+		key, val, _ := strings.Cut(part, "=")
+		options[strings.TrimSpace(strings.ToLower(key))] = val
+	}
+	return options
+}
+
+// names returns the variable names of a field in lookup order, like the runtime parser.
+func (g *generator) names(fieldName string, opts map[string]string, prefix string) []string {
+	var names []string
+	add := func(list ...string) {
+		for _, n := range list {
+			n = g.cfg.NamePrefix + prefix + n
+			if !slices.Contains(names, n) {
+				names = append(names, n)
+			}
+		}
+	}
+	if name := opts[topt.NAME]; name != "" {
+		add(strings.Split(name, g.cfg.SliceValueSeparator)...)
+	}
+	if _, exact := opts[topt.EXACT]; exact {
+		if len(names) == 0 {
+			add(fieldName)
+		}
+		return names
+	}
+	add(fieldName, strings.ToUpper(fieldName), strings.ToLower(fieldName))
+	return names
+}
+
+// typeKind classifies the supported field types.
+func (g *generator) typeKind(expr ast.Expr) (string, error) {
+	if g.texts[exprString(expr)] {
+		return "text", nil
+	}
+	switch exprString(expr) {
+	case "string":
+		return "string", nil
+	case "bool":
+		return "bool", nil
+	case "int", "int8", "int16", "int32", "int64":
+		return "int", nil
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return "uint", nil
+	case "float32", "float64":
+		return "float", nil
+	case "time.Duration":
+		return "duration", nil
+	}
+	return "", fmt.Errorf("type %s is not supported by go-envgen, use the runtime parser", exprString(expr))
+}
+
+// checkBoundLiteral checks that a bound is a valid literal for the kind of the field.
+func checkBoundLiteral(kind, bound string) error {
+	var err error
+	switch kind {
+	case "int":
+		_, err = strconv.ParseInt(bound, 10, 64)
+	case "uint":
+		_, err = strconv.ParseUint(bound, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(bound, 64)
+	}
+	return err
+}
+
+// exprString formats a type expression (e.g., time.Duration).
+func exprString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(t.X)
+	case *ast.ArrayType:
+		return "[]" + exprString(t.Elt)
+	}
+	return fmt.Sprintf("%T", expr)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleSource = `package sample
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Database struct {
+	Host string ` + "`env:\"name=HOST,default=localhost\"`" + `
+	Port int    ` + "`env:\"name=PORT,default=5432,min=1,max=65535\"`" + `
+}
+
+type Config struct {
+	Name     string        ` + "`env:\"name=APP_NAME,required\"`" + `
+	Mode     string        ` + "`env:\"name=MODE,default=safe,lower,oneof=safe|fast\"`" + `
+	Debug    bool          ` + "`env:\"name=DEBUG,default=false\"`" + `
+	Ratio    float32       ` + "`env:\"name=RATIO,default=0.5,lte=1\"`" + `
+	Timeout  time.Duration ` + "`env:\"name=TIMEOUT,default=5s\"`" + `
+	Hosts    []string      ` + "`env:\"name=HOSTS\"`" + `
+	Ports    []uint16      ` + "`env:\"name=PORTS,default=80|443,gt=0\"`" + `
+	Database Database      ` + "`env:\"prefix=DB_\"`" + `
+	Replica  Replica       ` + "`env:\"prefix=REPLICA_\"`" + `
+	Level    Level         ` + "`env:\"name=LEVEL\"`" + `
+	Levels   []Level       ` + "`env:\"name=LEVELS\"`" + `
+	Upstream Endpoint      ` + "`env:\"name=UPSTREAM\"`" + `
+	Ignored  string
+}
+
+type Level int
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "", "info":
+		*l = 0
+	case "debug":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func (d Database) Validate() error {
+	if d.Host == "localhost" && d.Port == 1 {
+		return fmt.Errorf("port %d is reserved on localhost", d.Port)
+	}
+	return nil
+}
+
+// Replica is validated through the Validate method promoted from Database
+type Replica struct {
+	Database
+}
+
+func (c *Config) Validate() error {
+	if c.Mode == "fast" && c.Debug {
+		return fmt.Errorf("debug is not supported in fast mode")
+	}
+	return nil
+}
+
+// Endpoint is decoded as a whole rather than recursed into
+type Endpoint struct {
+	Host string ` + "`env:\"name=HOST\"`" + `
+	Port string
+}
+
+func (e *Endpoint) UnmarshalText(text []byte) error {
+	e.Host, e.Port, _ = strings.Cut(string(text), ":")
+	return nil
+}
+`
+
+// sampleMain compares the generated method with the runtime parser for the environment of the process.
+const sampleMain = `package sample
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	env "github.com/igwtcode/go-env"
+)
+
+func TestEquivalence(t *testing.T) {
+	var generated, runtime Config
+	genErr := generated.UnmarshalEnv()
+	runErr := env.NewParser().WithNamePrefix("APP_").Unmarshal(&runtime)
+	if fmt.Sprint(genErr) != fmt.Sprint(runErr) {
+		t.Fatalf("errors differ:\ngenerated: %v\nruntime:   %v", genErr, runErr)
+	}
+	if !reflect.DeepEqual(generated, runtime) {
+		t.Fatalf("values differ:\ngenerated: %+v\nruntime:   %+v", generated, runtime)
+	}
+}
+`
+
+func TestGenerateMatchesRuntimeParser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a temporary module")
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module sample\n\ngo 1.22\n\nrequire github.com/igwtcode/go-env v0.0.0\n\nreplace github.com/igwtcode/go-env => " + root + "\n",
+		"config.go":      sampleSource,
+		"config_test.go": sampleMain,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := config{TagOptionSeparator: ",", SliceValueSeparator: "|", NamePrefix: "APP_"}
+	if err := run(dir, filepath.Join(dir, "config_envgen.go"), []string{"Config"}, cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cases := map[string][]string{
+		"defaults":       {"APP_APP_NAME=svc"},
+		"values":         {"APP_APP_NAME=svc", "APP_MODE= FAST ", "APP_DEBUG=1", "APP_RATIO=0.25", "APP_TIMEOUT=1m", "APP_HOSTS=a| |b", "APP_PORTS=8080", "APP_DB_PORT=6543"},
+		"missing":        {},
+		"invalid oneof":  {"APP_APP_NAME=svc", "APP_MODE=slow"},
+		"out of range":   {"APP_APP_NAME=svc", "APP_DB_PORT=70000"},
+		"invalid number": {"APP_APP_NAME=svc", "APP_PORTS=80|x"},
+		"zero element":   {"APP_APP_NAME=svc", "APP_PORTS=0"},
+		"text":           {"APP_APP_NAME=svc", "APP_LEVEL=debug", "APP_LEVELS=info| debug", "APP_UPSTREAM=db:5432"},
+		"invalid text":   {"APP_APP_NAME=svc", "APP_LEVELS=info|loud"},
+		"invalid struct": {"APP_APP_NAME=svc", "APP_MODE=fast", "APP_DEBUG=true", "APP_DB_PORT=1", "APP_REPLICA_PORT=1"},
+		"bom and cr":     {"APP_APP_NAME=\ufeffsvc\r", "APP_DEBUG=true\r", "APP_PORTS=8080\r"},
+	}
+	for name, environ := range cases {
+		cmd := exec.Command("go", "test", "-count=1", ".")
+		cmd.Dir = dir
+		cmd.Env = append(filteredEnviron(), environ...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("%s: generated code differs from the runtime parser: %v\n%s", name, err, out)
+		}
+	}
+}
+
+// filteredEnviron returns the environment of the test without variables the sample reads.
+func filteredEnviron() []string {
+	var environ []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "APP_") {
+			environ = append(environ, kv)
+		}
+	}
+	return append(environ, "GOFLAGS=-mod=mod")
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	src := "package sample\n\ntype Config struct {\n\tURL string `env:\"name=URL,expand\"`\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := loadPackage(dir, config{TagOptionSeparator: ",", SliceValueSeparator: "|"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = g.generate([]string{"Config"})
+	if err == nil || !strings.Contains(err.Error(), `Config.URL: option "expand" is not supported`) {
+		t.Errorf("expected an unsupported option error, got %v", err)
+	}
+	if _, err := g.generate([]string{"Missing"}); err == nil {
+		t.Errorf("expected an error for a missing type")
+	}
+
+	// Structs decoded from JSON are not recursed into
+	src = "package sample\n\ntype Backend struct {\n\tHost string `env:\"name=HOST\"`\n}\n\ntype Config struct {\n\tBackend Backend `env:\"name=BACKEND,json\"`\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if g, err = loadPackage(dir, config{TagOptionSeparator: ",", SliceValueSeparator: "|"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = g.generate([]string{"Config"})
+	if err == nil || !strings.Contains(err.Error(), `Config.Backend: option "json" is not supported`) {
+		t.Errorf("expected an unsupported option error, got %v", err)
+	}
+
+	// Untagged structs of other packages cannot be recursed into
+	src = "package sample\n\nimport \"github.com/igwtcode/go-env/envhttp\"\n\ntype Config struct {\n\tServer envhttp.ServerConfig\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if g, err = loadPackage(dir, config{TagOptionSeparator: ",", SliceValueSeparator: "|"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = g.generate([]string{"Config"})
+	if err == nil || !strings.Contains(err.Error(), "Config.Server: nested type envhttp.ServerConfig of another package is not supported") {
+		t.Errorf("expected an unsupported type error, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Command go-envgen generates reflection-free UnmarshalEnv methods for structs tagged for the env package,
+// for binaries where startup time and binary introspection matter.
+//
+// Usage, next to the struct declaration:
+//
+//	//go:generate go run github.com/igwtcode/go-env/cmd/go-envgen -type Config
+//
+// The generated method reads the same variables, strips byte-order marks, carriage returns and surrounding
+// whitespace, applies the same defaults and validations, and reports errors like env.Parser.Unmarshal. It
+// supports the basic types (strings, booleans, integers, floats, durations and slices of them), types of the
+// package with an UnmarshalText method, and the options name, default, required, notrim, lower, upper, exact,
+// min, max, gt, gte, lt, lte, oneof, prefix and flatten. Validate methods of the struct types are called
+// once all fields are populated. Structs using other types or options, or nesting structs of other packages,
+// fail the generation and remain populated with the runtime parser.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/igwtcode/go-env"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names (required)")
+	output := flag.String("output", "", "output file name (default: <type>_envgen.go)")
+	dir := flag.String("dir", ".", "directory of the package")
+	namePrefix := flag.String("prefix", "", "name prefix of the environment variables, as set with WithNamePrefix")
+	tagSep := flag.String("tagsep", env.DefaultTagOptionSeparator, "tag option separator")
+	sliceSep := flag.String("slicesep", env.DefaultSliceValueSeparator, "slice value separator")
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+	if *output == "" {
+		*output = strings.ToLower(types[0]) + "_envgen.go"
+	}
+
+	if err := run(*dir, filepath.Join(*dir, *output), types, config{
+		TagOptionSeparator:  *tagSep,
+		SliceValueSeparator: *sliceSep,
+		NamePrefix:          *namePrefix,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "go-envgen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the methods of the struct types of the package in dir and writes them to output.
+func run(dir, output string, types []string, cfg config) error {
+	g, err := loadPackage(dir, cfg)
+	if err != nil {
+		return err
+	}
+	src, err := g.generate(types)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
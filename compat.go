@@ -0,0 +1,136 @@
+package env
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// Tags and defaults of github.com/caarlos0/env, read with CaarlosTags.
+const (
+	caarlosDefaultTag   = "envDefault"
+	caarlosSeparatorTag = "envSeparator"
+	caarlosPrefixTag    = "envPrefix"
+	caarlosSeparator    = ","
+)
+
+// Tags of github.com/kelseyhightower/envconfig, read with EnvconfigTags.
+const (
+	envconfigNameTag       = "envconfig"
+	envconfigDefaultTag    = "default"
+	envconfigRequiredTag   = "required"
+	envconfigSplitWordsTag = "split_words"
+	envconfigIgnoredTag    = "ignored"
+	envconfigSeparator     = ","
+)
+
+// WithCaarlosTags configures whether struct tags are read in the style of github.com/caarlos0/env, so projects
+// can switch to this package without rewriting their tags at once:
+//
+//	Port  int      `env:"PORT,required" envDefault:"8080"`
+//	Hosts []string `env:"HOSTS" envSeparator:":"`
+//	DB    Database `envPrefix:"DB_"`
+//
+// The first part of the `env` tag is the variable name, read as is; fields without a name are not bound.
+// The `required`, `notEmpty`, `file`, `unset` and `expand` options are supported, slices are separated by
+// commas unless `envSeparator` says otherwise, and the parser's name prefix still applies. Native options
+// of this package are not read in this mode.
+func (p *Parser) WithCaarlosTags(enabled bool) *Parser {
+	p.CaarlosTags = enabled
+	return p
+}
+
+// compatTags reports whether tags are read in the style of another package, whose unset fields are left unchanged.
+func (p *Parser) compatTags() bool {
+	return p.CaarlosTags || p.EnvconfigTags
+}
+
+// caarlosMeta derives the metadata of a field from caarlos0/env style tags.
+func (p *Parser) caarlosMeta(field reflect.StructField, prefix string) fieldMeta {
+	tagVal, tagOk := field.Tag.Lookup("env")
+	if tagVal == "-" {
+		return fieldMeta{tagOk: true, skip: true}
+	}
+
+	tagOptions := map[string]string{topt.EXACT: "", topt.SEPARATOR: caarlosSeparator}
+	if own, ok := field.Tag.Lookup(caarlosPrefixTag); ok {
+		tagOptions[topt.PREFIX] = own
+	}
+	if def, ok := field.Tag.Lookup(caarlosDefaultTag); ok {
+		tagOptions[topt.DEFAULT] = def
+	}
+	if sep, ok := field.Tag.Lookup(caarlosSeparatorTag); ok && sep != "" {
+		tagOptions[topt.SEPARATOR] = sep
+	}
+
+	parts := strings.Split(tagVal, ",")
+	for _, opt := range parts[1:] {
+		switch opt = strings.ToLower(strings.TrimSpace(opt)); opt {
+		case topt.REQUIRED, topt.FILE, topt.UNSET, topt.EXPAND:
+			tagOptions[opt] = ""
+		case topt.NOTEMPTY:
+			// caarlos0/env also rejects unset variables without a default
+			tagOptions[topt.NOTEMPTY] = ""
+			tagOptions[topt.REQUIRED] = ""
+		}
+	}
+
+	name := strings.TrimSpace(parts[0])
+	if !tagOk || name == "" {
+		return fieldMeta{tagOptions: tagOptions}
+	}
+	tagOptions[topt.NAME] = name
+	return fieldMeta{tagOk: true, tagOptions: tagOptions, names: []string{p.NamePrefix + prefix + name}}
+}
+
+// WithEnvconfigTags configures whether struct tags are read in the style of github.com/kelseyhightower/envconfig,
+// binding every exported field:
+//
+//	Port     int    `default:"8080"`                    // PORT
+//	MaxConns int    `split_words:"true" required:"true"` // MAX_CONNS
+//	Token    string `envconfig:"API_TOKEN"`              // API_TOKEN, then API_TOKEN without the name prefix
+//	Debug    bool   `ignored:"true"`
+//
+// Names are derived from the field names in upper case, and nested structs prefix the names of their fields
+// with their own name. Slices are separated by commas. Use the envconfig package for a drop-in Process function.
+func (p *Parser) WithEnvconfigTags(enabled bool) *Parser {
+	p.EnvconfigTags = enabled
+	return p
+}
+
+// envconfigMeta derives the metadata of a field from envconfig style tags.
+func (p *Parser) envconfigMeta(field reflect.StructField, prefix string) fieldMeta {
+	if ignored, _ := strconv.ParseBool(field.Tag.Get(envconfigIgnoredTag)); ignored {
+		return fieldMeta{tagOk: true, skip: true}
+	}
+
+	alt := strings.ToUpper(field.Tag.Get(envconfigNameTag))
+	key := alt
+	if key == "" {
+		key = strings.ToUpper(field.Name)
+		if split, _ := strconv.ParseBool(field.Tag.Get(envconfigSplitWordsTag)); split {
+			key = toSnakeCase(field.Name)
+		}
+	}
+
+	tagOptions := map[string]string{topt.NAME: key, topt.EXACT: "", topt.SEPARATOR: envconfigSeparator}
+	if def, ok := field.Tag.Lookup(envconfigDefaultTag); ok {
+		tagOptions[topt.DEFAULT] = def
+	}
+	if required, _ := strconv.ParseBool(field.Tag.Get(envconfigRequiredTag)); required {
+		tagOptions[topt.REQUIRED] = ""
+	}
+	// Nested structs prefix the names of their fields, unless they are embedded without a name
+	if !field.Anonymous || alt != "" {
+		tagOptions[topt.PREFIX] = key + "_"
+	}
+
+	// The name given in the tag is also read without any prefix
+	names := []string{p.NamePrefix + prefix + key}
+	if alt != "" && alt != names[0] {
+		names = append(names, alt)
+	}
+	return fieldMeta{tagOk: true, tagOptions: tagOptions, names: names}
+}
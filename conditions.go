@@ -0,0 +1,92 @@
+package env
+
+import (
+	"strings"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// requiredIfCheck is a pending `required_if` check of a field.
+type requiredIfCheck struct {
+	path      string   // Path of the struct containing the field
+	fieldPath string   // Path of the field
+	names     []string // Environment variable names of the field
+	cond      string   // Condition: "OTHER" (is set) or "OTHER=value"
+}
+
+// checkRequiredIf fails for the first field whose condition holds while it has no value.
+func (p *Parser) checkRequiredIf(st *decodeState) error {
+	for _, c := range st.requiredIf {
+		if st.value(c.fieldPath) != "" {
+			continue
+		}
+		if p.conditionHolds(st, c.path, c.cond) {
+			return &RequiredError{Field: c.fieldPath, Names: c.names, Condition: c.cond, root: st.root, separator: p.SliceValueSeparator}
+		}
+	}
+	return nil
+}
+
+// conditionHolds evaluates a condition of the struct at path: "OTHER" holds when the referenced value is set,
+// "OTHER=value" when it equals the value (case-insensitive).
+func (p *Parser) conditionHolds(st *decodeState, path string, cond string) bool {
+	ref, want, hasWant := strings.Cut(cond, "=")
+	got := p.lookupRef(st, path, strings.TrimSpace(ref))
+	if hasWant {
+		return strings.EqualFold(got, strings.TrimSpace(want))
+	}
+	return got != ""
+}
+
+// conditionalDefault returns the default of the first `default_if` entry ("CONDITION:default") whose
+// condition holds. Only fields declared before the field are resolved at this point.
+func (p *Parser) conditionalDefault(st *decodeState, path string, entries string) (string, bool) {
+	for _, entry := range strings.Split(entries, p.SliceValueSeparator) {
+		cond, def, ok := strings.Cut(entry, ":")
+		if ok && p.conditionHolds(st, path, cond) {
+			return def, true
+		}
+	}
+	return "", false
+}
+
+// conditions returns the conditions of the `required_if` and `default_if` options of a field.
+func (p *Parser) conditions(tagOptions map[string]string) []string {
+	var conds []string
+	if cond, ok := tagOptions[topt.REQUIRED_IF]; ok {
+		conds = append(conds, cond)
+	}
+	if entries, ok := tagOptions[topt.DEFAULT_IF]; ok {
+		for _, entry := range strings.Split(entries, p.SliceValueSeparator) {
+			cond, _, _ := strings.Cut(entry, ":")
+			conds = append(conds, cond)
+		}
+	}
+	return conds
+}
+
+// lookupRef returns the value referenced by a condition: a sibling field of the struct at path,
+// a field by its full path, or else an environment variable (with, then without the name prefix).
+func (p *Parser) lookupRef(st *decodeState, path string, ref string) string {
+	for _, candidate := range []string{joinPath(path, ref), ref} {
+		for _, r := range st.records {
+			if r.Path == candidate {
+				return r.Value
+			}
+		}
+	}
+	if val := p.getenv(p.NamePrefix + ref); val != "" {
+		return val
+	}
+	return p.getenv(ref)
+}
+
+// value returns the resolved value of the field at the given path.
+func (st *decodeState) value(fieldPath string) string {
+	for _, r := range st.records {
+		if r.Path == fieldPath {
+			return r.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package env
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+)
+
+// ConverterFunc converts a raw environment variable value into a value of a specific type.
+type ConverterFunc func(val string) (interface{}, error)
+
+// builtinConverters holds the converters for types provided by this package.
+var builtinConverters = map[reflect.Type]ConverterFunc{
+	reflect.TypeOf(TimeWindow{}):       func(val string) (interface{}, error) { return ParseTimeWindow(val) },
+	reflect.TypeOf(ByteSize(0)):        func(val string) (interface{}, error) { return ParseByteSize(val) },
+	reflect.TypeOf(url.URL{}):          convertURL,
+	reflect.TypeOf(net.HardwareAddr{}): func(val string) (interface{}, error) { return net.ParseMAC(val) },
+}
+
+// converter returns the converter registered for the type, preferring the parser's own converters.
+func (p *Parser) converter(t reflect.Type) ConverterFunc {
+	if fn, ok := p.Converters[t]; ok {
+		return fn
+	}
+	return builtinConverters[t]
+}
+
+// setConverted converts the value using the converter and assigns the result to the field.
+func setConverted(field reflect.Value, val string, conv ConverterFunc) error {
+	out, err := conv(val)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(out)
+	if !rv.IsValid() {
+		return fmt.Errorf("converter for type %s returned nil", field.Type())
+	}
+	if !rv.Type().AssignableTo(field.Type()) {
+		if !rv.Type().ConvertibleTo(field.Type()) {
+			return fmt.Errorf("converter for type %s returned incompatible type %s", field.Type(), rv.Type())
+		}
+		rv = rv.Convert(field.Type())
+	}
+	field.Set(rv)
+	return nil
+}
+
+// convertURL parses the value as a URL.
+func convertURL(val string) (interface{}, error) {
+	u, err := url.Parse(val)
+	if err != nil {
+		return nil, err
+	}
+	return *u, nil
+}
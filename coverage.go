@@ -0,0 +1,69 @@
+package env
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TestingT is the subset of testing.TB used by Coverage.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Coverage records which struct fields were resolved from environment variables across Unmarshal calls.
+// Attach it to a parser with WithCoverage during tests, then assert that every field was exercised.
+// It is safe for concurrent use.
+type Coverage struct {
+	mu     sync.Mutex
+	fields map[string]bool // Covered state per "Type.Field.Path"
+}
+
+// NewCoverage creates an empty coverage recorder.
+func NewCoverage() *Coverage {
+	return &Coverage{fields: map[string]bool{}}
+}
+
+// record marks the fields of a decoded struct, keeping fields covered once they were set from the environment.
+func (c *Coverage) record(t reflect.Type, records []fieldRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range records {
+		key := t.String() + "." + r.Path
+		c.fields[key] = c.fields[key] || r.Source == SourceEnv
+	}
+}
+
+// Covered returns the sorted fields that were resolved from an environment variable at least once.
+func (c *Coverage) Covered() []string {
+	return c.list(true)
+}
+
+// Uncovered returns the sorted fields that were never resolved from an environment variable.
+func (c *Coverage) Uncovered() []string {
+	return c.list(false)
+}
+
+// list returns the sorted fields with the given covered state.
+func (c *Coverage) list(covered bool) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []string
+	for key, ok := range c.fields {
+		if ok == covered {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AssertComplete reports a test error listing the fields that were never resolved from an environment variable.
+func (c *Coverage) AssertComplete(t TestingT) {
+	t.Helper()
+	if uncovered := c.Uncovered(); len(uncovered) > 0 {
+		t.Errorf("config fields not covered by any test: %s", strings.Join(uncovered, ", "))
+	}
+}
@@ -0,0 +1,39 @@
+package env
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedValuePrefix marks a value as base64-encoded ciphertext to decrypt, e.g., enc:AQICAH...
+const EncryptedValuePrefix = "enc:"
+
+// DecryptFunc decrypts ciphertext, e.g., with AWS KMS.
+type DecryptFunc func(ctx context.Context, ciphertext []byte) ([]byte, error)
+
+// WithDecryptor decrypts values of the form `enc:<base64 ciphertext>` with fn before they are validated and
+// converted, so env files only hold ciphertext and the plaintext is only kept in memory. Decrypted values are
+// masked in reports. Fields with the `encrypted` option reject values that are not encrypted.
+func (p *Parser) WithDecryptor(fn DecryptFunc) *Parser {
+	p.Decryptor = fn
+	return p
+}
+
+// decryptValue decrypts an `enc:` value. Errors do not include the value.
+func (p *Parser) decryptValue(ctx context.Context, val string) (string, error) {
+	if p.Decryptor == nil {
+		return "", errors.New("encrypted value but no decryptor is configured")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(val, EncryptedValuePrefix))
+	if err != nil {
+		return "", errors.New("encrypted value is not valid base64")
+	}
+	plaintext, err := p.Decryptor(ctx, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("decrypting value: %w", err)
+	}
+	return string(plaintext), nil
+}
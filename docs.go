@@ -0,0 +1,140 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// FieldDoc documents a field read from environment variables.
+type FieldDoc struct {
+	Field      string   // Dotted field path (e.g., Database.Port)
+	Names      []string // Environment variable names in lookup order
+	Type       string   // Go type of the field (e.g., time.Duration)
+	Default    string   // Default value, if any
+	Required   bool     // Whether the field must be set
+	Sensitive  bool     // Whether the value is a secret
+	Validators []string // Validation options of the field (e.g., "min=1", "v_aws_region")
+}
+
+// docValidators lists the tag options reported as validators, in display order.
+var docValidators = []string{
+	topt.MIN, topt.MAX, topt.GT, topt.GTE, topt.LT, topt.LTE, topt.MULTIPLEOF, topt.NONZERO,
+	topt.LEN, topt.ALPHANUM, topt.ASCII, topt.REGEX, topt.ONEOF, topt.NOTEMPTY, topt.UNIQUE, topt.REQUIRED_IF,
+	topt.V_MAC, topt.V_DATETIME, topt.V_JSON, topt.V_BASE64,
+	topt.V_AWS_REGION, topt.V_AWS_ACCOUNT_ID, topt.V_AWS_ROLE_ARN, topt.V_AWS_BUCKET_NAME, topt.V_AWS_SQS_QUEUE_URL,
+	topt.V_AWS_SNS_TOPIC_ARN, topt.V_AWS_LAMBDA, topt.V_AWS_KMS_KEY,
+}
+
+// Document describes the fields of the struct read from environment variables, using a parser with
+// the default configuration.
+func Document(envStruct interface{}) ([]FieldDoc, error) {
+	return NewParser().Document(envStruct)
+}
+
+// Document describes the fields of the struct read from environment variables: their names, types, defaults,
+// and requirements. It only inspects the struct tags, the environment is not read.
+func (p *Parser) Document(envStruct interface{}) ([]FieldDoc, error) {
+	t := reflect.TypeOf(envStruct)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct or a pointer to a struct, got %T", envStruct)
+	}
+
+	var docs []FieldDoc
+	for _, f := range p.fields(t) {
+		required := p.isRequired(f.tagOptions)
+		_, sensitive := f.tagOptions[topt.SENSITIVE]
+		doc := FieldDoc{
+			Field:     f.path,
+			Names:     p.names(f),
+			Type:      f.field.Type.String(),
+			Default:   f.tagOptions[topt.DEFAULT],
+			Required:  required,
+			Sensitive: sensitive,
+		}
+		for _, opt := range slices.Concat(docValidators, p.validatorNames()) {
+			if val, ok := f.tagOptions[opt]; ok {
+				if val != "" {
+					opt += "=" + val
+				}
+				doc.Validators = append(doc.Validators, opt)
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// WriteMarkdown renders the field documentation as a Markdown table, for READMEs and runbooks.
+func WriteMarkdown(w io.Writer, docs []FieldDoc) error {
+	var b strings.Builder
+	b.WriteString("| Variable | Type | Default | Required | Validation |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, d := range docs {
+		names := make([]string, len(d.Names))
+		for i, name := range d.Names {
+			names[i] = markdownCode(name)
+		}
+		required := "no"
+		if d.Required {
+			required = "yes"
+		}
+		validators := make([]string, len(d.Validators))
+		for i, v := range d.Validators {
+			validators[i] = markdownCode(v)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			strings.Join(names, ", "), markdownCode(d.Type), markdownCode(d.Default), required, strings.Join(validators, " "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Usage writes a flag-style help listing of the environment variables the struct reads, for `--help`
+// output or when required variables are missing.
+func (p *Parser) Usage(envStruct interface{}, w io.Writer) error {
+	docs, err := p.Document(envStruct)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("Environment variables:\n")
+	for _, d := range docs {
+		fmt.Fprintf(&b, "  %s %s\n", d.Names[0], d.Type)
+		var details []string
+		if d.Required {
+			details = append(details, "required")
+		}
+		if d.Default != "" {
+			details = append(details, fmt.Sprintf("default %q", d.Default))
+		}
+		if d.Sensitive {
+			details = append(details, "sensitive")
+		}
+		details = append(details, d.Validators...)
+		if len(d.Names) > 1 {
+			details = append(details, "also read from "+strings.Join(d.Names[1:], ", "))
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&b, "    \t%s\n", strings.Join(details, "; "))
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCode formats a value as inline code for a Markdown table cell, escaping pipes.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
@@ -0,0 +1,95 @@
+package env_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+type docsConfig struct {
+	Host    string        `env:"name=HOST,required"`
+	Port    int           `env:"name=PORT,default=8080,min=1,max=65535"`
+	Mode    string        `env:"name=MODE,default=safe,oneof=safe|fast"`
+	Timeout time.Duration `env:"name=TIMEOUT,default=5s"`
+	Token   string        `env:"name=TOKEN,sensitive"`
+	Ignored string
+	DB      struct {
+		Region string `env:"name=REGION,v_aws_region"`
+	} `env:"prefix=DB_"`
+}
+
+func TestDocument(t *testing.T) {
+	docs, err := env.NewParser().WithNamePrefix("APP_").Document(&docsConfig{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := []env.FieldDoc{
+		{Field: "Host", Names: []string{"APP_HOST", "APP_Host", "APP_host"}, Type: "string", Required: true},
+		{Field: "Port", Names: []string{"APP_PORT", "APP_Port", "APP_port"}, Type: "int", Default: "8080", Validators: []string{"min=1", "max=65535"}},
+		{Field: "Mode", Names: []string{"APP_MODE", "APP_Mode", "APP_mode"}, Type: "string", Default: "safe", Validators: []string{"oneof=safe|fast"}},
+		{Field: "Timeout", Names: []string{"APP_TIMEOUT", "APP_Timeout", "APP_timeout"}, Type: "time.Duration", Default: "5s"},
+		{Field: "Token", Names: []string{"APP_TOKEN", "APP_Token", "APP_token"}, Type: "string", Sensitive: true},
+		{Field: "DB.Region", Names: []string{"APP_DB_REGION", "APP_DB_Region", "APP_DB_region"}, Type: "string", Validators: []string{"v_aws_region"}},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("expected %+v, got %+v", want, docs)
+	}
+
+	if _, err := env.Document(42); err == nil {
+		t.Errorf("expected an error for a non-struct value")
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	type Config struct {
+		Port int    `env:"name=PORT,default=8080,min=1,exact"`
+		Mode string `env:"name=MODE,oneof=safe|fast,required,exact"`
+	}
+
+	docs, err := env.Document(Config{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := env.WriteMarkdown(&buf, docs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := "| Variable | Type | Default | Required | Validation |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `PORT` | `int` | `8080` | no | `min=1` |\n" +
+		"| `MODE` | `string` |  | yes | `oneof=safe\\|fast` |\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestUsage(t *testing.T) {
+	type Config struct {
+		Host  string `env:"name=HOST|SERVER_HOST,required,exact"`
+		Port  int    `env:"name=PORT,default=8080,min=1,exact"`
+		Token string `env:"name=TOKEN,sensitive,exact"`
+		Debug bool   `env:"name=DEBUG,exact"`
+	}
+
+	var buf bytes.Buffer
+	if err := env.NewParser().Usage(&Config{}, &buf); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := "Environment variables:\n" +
+		"  HOST string\n" +
+		"    \trequired; also read from SERVER_HOST\n" +
+		"  PORT int\n" +
+		"    \tdefault \"8080\"; min=1\n" +
+		"  TOKEN string\n" +
+		"    \tsensitive\n" +
+		"  DEBUG bool\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// WithDotenv configures dotenv files to load before populating a struct. Their variables are merged beneath
+// the real environment variables: a variable set in the environment always wins, and later files override
+// earlier ones (e.g., WithDotenv(".env", ".env.local")). Missing files are skipped.
+func (p *Parser) WithDotenv(files ...string) *Parser {
+	p.DotenvFiles = files
+	return p
+}
+
+// loadDotenvFiles reads the dotenv files in order, later files overriding the variables of earlier ones.
+func loadDotenvFiles(files []string) (map[string]string, error) {
+	vals := map[string]string{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dotenv file: %w", err)
+		}
+		if err := parseDotenv(file, string(content), vals); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// parseDotenv parses the content of a dotenv file into vals.
+//
+// Each line holds a KEY=VALUE pair, optionally preceded by `export`. Blank lines and lines starting with '#'
+// are ignored. Unquoted values end at a ' #' comment and are trimmed. Single-quoted values are taken literally,
+// double-quoted values support the escapes \n, \r, \t, \" and \\. Both may span multiple lines.
+func parseDotenv(file, content string, vals map[string]string) error {
+	content = strings.ReplaceAll(strings.TrimPrefix(content, "\uFEFF"), "\r\n", "\n")
+	line := 1
+	for content != "" {
+		var raw string
+		raw, content, _ = strings.Cut(content, "\n")
+		start := line
+		line++
+
+		raw = strings.TrimLeft(raw, " \t")
+		if strings.TrimSpace(raw) == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(raw, "export"); ok && rest != "" && unicode.IsSpace(rune(rest[0])) {
+			raw = strings.TrimLeft(rest, " \t")
+		}
+
+		key, val, ok := strings.Cut(raw, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("%s:%d: invalid line, expected KEY=VALUE", file, start)
+		}
+		val = strings.TrimLeft(val, " \t")
+
+		if val == "" || (val[0] != '"' && val[0] != '\'') {
+			if i := strings.Index(val, " #"); i >= 0 {
+				val = val[:i]
+			}
+			vals[key] = strings.TrimSpace(val)
+			continue
+		}
+
+		// Quoted values may continue on the following lines up to the closing quote
+		quote := val[0]
+		val = val[1:]
+		for {
+			end := closingQuote(val, quote)
+			if end >= 0 {
+				if trailing := strings.TrimSpace(val[end+1:]); trailing != "" && !strings.HasPrefix(trailing, "#") {
+					return fmt.Errorf("%s:%d: unexpected characters after the closing quote", file, start)
+				}
+				val = val[:end]
+				break
+			}
+			if content == "" {
+				return fmt.Errorf("%s:%d: unterminated quoted value", file, start)
+			}
+			var next string
+			next, content, _ = strings.Cut(content, "\n")
+			val += "\n" + next
+			line++
+		}
+
+		if quote == '"' {
+			val = unescapeDotenv(val)
+		}
+		vals[key] = val
+	}
+	return nil
+}
+
+// closingQuote returns the index of the closing quote in the value, skipping escaped double quotes,
+// or -1 if the value has no closing quote.
+func closingQuote(val string, quote byte) int {
+	for i := 0; i < len(val); i++ {
+		switch {
+		case quote == '"' && val[i] == '\\':
+			i++
+		case val[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDotenv resolves the escape sequences of a double-quoted dotenv value.
+func unescapeDotenv(val string) string {
+	var b strings.Builder
+	for i := 0; i < len(val); i++ {
+		if val[i] != '\\' || i+1 == len(val) {
+			b.WriteByte(val[i])
+			continue
+		}
+		i++
+		switch val[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(val[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(val[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package env_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+)
+
+func writeDotenv(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return path
+}
+
+func TestWithDotenv(t *testing.T) {
+	dir := t.TempDir()
+	base := writeDotenv(t, dir, ".env", `# base settings
+DOTENV_HOST=localhost
+export DOTENV_PORT=8080
+DOTENV_NAME=app # inline comment
+DOTENV_LITERAL='no\nescape # kept'
+DOTENV_ESCAPED="tab\there \"quoted\""
+DOTENV_CERT="-----BEGIN-----
+line
+-----END-----"
+DOTENV_OVERRIDE=base
+`)
+	local := writeDotenv(t, dir, ".env.local", "DOTENV_OVERRIDE=local\r\nDOTENV_HOST=local-host\r\n")
+
+	type Config struct {
+		Host     string `env:"name=DOTENV_HOST"`
+		Port     int    `env:"name=DOTENV_PORT"`
+		Name     string `env:"name=DOTENV_NAME"`
+		Literal  string `env:"name=DOTENV_LITERAL"`
+		Escaped  string `env:"name=DOTENV_ESCAPED"`
+		Cert     string `env:"name=DOTENV_CERT"`
+		Override string `env:"name=DOTENV_OVERRIDE"`
+	}
+
+	os.Setenv("DOTENV_HOST", "from-env")
+	defer os.Unsetenv("DOTENV_HOST")
+
+	var cfg Config
+	err := env.NewParser().WithDotenv(base, local, filepath.Join(dir, ".env.missing")).Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := Config{
+		Host:     "from-env",
+		Port:     8080,
+		Name:     "app",
+		Literal:  `no\nescape # kept`,
+		Escaped:  "tab\there \"quoted\"",
+		Cert:     "-----BEGIN-----\nline\n-----END-----",
+		Override: "local",
+	}
+	if cfg != want {
+		t.Errorf("expected %+v, got %+v", want, cfg)
+	}
+	if _, ok := os.LookupEnv("DOTENV_PORT"); ok {
+		t.Errorf("expected dotenv values not to be written to the environment")
+	}
+}
+
+func TestWithDotenvInvalid(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"NO_EQUALS\n":             "invalid line",
+		"A=1\nB=\"unterminated\n": "unterminated quoted value",
+		"C='quoted' trailing\n":   "unexpected characters after the closing quote",
+		"INVALID KEY=value\n":     "invalid line",
+	}
+	for content, want := range cases {
+		path := writeDotenv(t, dir, ".env", content)
+		var cfg struct {
+			Value string `env:"name=DOTENV_VALUE"`
+		}
+		err := env.NewParser().WithDotenv(path).Unmarshal(&cfg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", content, want, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// Dump renders a populated struct for startup logs, using a parser with the default configuration.
+func Dump(envStruct interface{}) string {
+	return NewParser().Dump(envStruct)
+}
+
+// DumpJSON renders a populated struct as a JSON object, using a parser with the default configuration.
+func DumpJSON(envStruct interface{}) ([]byte, error) {
+	return NewParser().DumpJSON(envStruct)
+}
+
+// Dump renders the fields of a populated struct read from environment variables, one `Path: value` line per
+// field in declaration order, for startup logs. Values of sensitive fields, and of fields read from files,
+// commands, resolvers or decryptors, are masked. The latter are known from the tag options, or from the
+// structs of the type populated by the parser. Fields tagged with "-" or without an `env` tag are left out.
+func (p *Parser) Dump(envStruct interface{}) string {
+	var b strings.Builder
+	for _, kv := range p.dumpFields(envStruct) {
+		fmt.Fprintf(&b, "%s: %s\n", kv[0], kv[1])
+	}
+	return b.String()
+}
+
+// DumpJSON renders the fields of a populated struct like Dump, as a JSON object mapping field paths to values.
+func (p *Parser) DumpJSON(envStruct interface{}) ([]byte, error) {
+	out := map[string]string{}
+	for _, kv := range p.dumpFields(envStruct) {
+		out[kv[0]] = kv[1]
+	}
+	return json.Marshal(out)
+}
+
+// dumpFields returns the paths and displayed values of the fields of the struct.
+func (p *Parser) dumpFields(envStruct interface{}) [][2]string {
+	v := reflect.Indirect(reflect.ValueOf(envStruct))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var out [][2]string
+	for _, f := range p.fields(v.Type()) {
+		fieldValue := v.FieldByIndex(f.index)
+		_, sensitive := f.tagOptions[topt.SENSITIVE]
+		sensitive = sensitive || p.secretSource(v.Type(), f)
+		_, isJSON := f.tagOptions[topt.JSON]
+		var val string
+		var err error
+		switch {
+		case fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil():
+		case sensitive && !fieldValue.IsZero():
+			val = maskedValue
+		case isJSON:
+			var b []byte
+			b, err = json.Marshal(fieldValue.Interface())
+			val = string(b)
+		default:
+			val, err = p.formatValue(fieldValue, f.tagOptions)
+		}
+		if err != nil {
+			val = fmt.Sprint(fieldValue.Interface())
+		}
+		out = append(out, [2]string{f.path, val})
+	}
+	return out
+}
+
+// secretFields records, per struct type, the fields whose values were read from a secret source (a file,
+// a command, a resolver or a decryptor), so Dump masks them once the references are gone (e.g., with `unset`).
+type secretFields struct {
+	mu    sync.Mutex
+	paths map[reflect.Type]map[string]bool
+}
+
+// record adds the masked fields of a decoded struct.
+func (s *secretFields) record(t reflect.Type, records []fieldRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		if !r.Masked {
+			continue
+		}
+		if s.paths == nil {
+			s.paths = map[reflect.Type]map[string]bool{}
+		}
+		if s.paths[t] == nil {
+			s.paths[t] = map[string]bool{}
+		}
+		s.paths[t][r.Path] = true
+	}
+}
+
+// has reports whether the field of the struct type was read from a secret source.
+func (s *secretFields) has(t reflect.Type, path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths[t][path]
+}
+
+// secretSource reports whether the value of a field is read from a secret source, as declared by its tag
+// options or as recorded when a struct of the type was populated by the parser.
+func (p *Parser) secretSource(t reflect.Type, f fieldInfo) bool {
+	_, file := f.tagOptions[topt.FILE]
+	_, encrypted := f.tagOptions[topt.ENCRYPTED]
+	if file || encrypted || p.tagReference(f.tagOptions) != "" {
+		return true
+	}
+	return p.secrets != nil && p.secrets.has(t, f.path)
+}
@@ -0,0 +1,89 @@
+package env_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+func TestDump(t *testing.T) {
+	type Database struct {
+		Host     string `env:"name=HOST"`
+		Password string `env:"name=PASSWORD,sensitive"`
+	}
+	type Config struct {
+		Port     int               `env:"name=PORT"`
+		Timeout  time.Duration     `env:"name=TIMEOUT"`
+		Hosts    []string          `env:"name=HOSTS"`
+		Labels   map[string]string `env:"name=LABELS,json"`
+		Token    string            `env:"name=TOKEN,sensitive"`
+		Limit    *int              `env:"name=LIMIT"`
+		Internal string            `env:"-"`
+		Ignored  string
+		Database Database `env:"prefix=DB_"`
+	}
+
+	cfg := Config{
+		Port:     8080,
+		Timeout:  5 * time.Second,
+		Hosts:    []string{"a", "b"},
+		Labels:   map[string]string{"team": "core"},
+		Internal: "internal",
+		Ignored:  "ignored",
+		Database: Database{Host: "db", Password: "s3cret"},
+	}
+
+	expected := `Port: 8080
+Timeout: 5s
+Hosts: a|b
+Labels: {"team":"core"}
+Token: 
+Limit: 
+Database.Host: db
+Database.Password: ***
+`
+	if got := env.Dump(&cfg); got != expected {
+		t.Errorf("expected dump:\n%s\ngot:\n%s", expected, got)
+	}
+
+	b, err := env.DumpJSON(cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expectedJSON := `{"Database.Host":"db","Database.Password":"***","Hosts":"a|b","Labels":"{\"team\":\"core\"}","Limit":"","Port":"8080","Timeout":"5s","Token":""}`
+	if string(b) != expectedJSON {
+		t.Errorf("expected JSON:\n%s\ngot:\n%s", expectedJSON, b)
+	}
+}
+
+func TestDumpMasksResolvedUnsetValues(t *testing.T) {
+	type Config struct {
+		Token string `env:"name=DUMP_TOKEN,unset"`
+		Host  string `env:"name=DUMP_HOST"`
+	}
+
+	os.Setenv("DUMP_TOKEN", "ssm:///app/token")
+	os.Setenv("DUMP_HOST", "db")
+	defer os.Unsetenv("DUMP_TOKEN")
+	defer os.Unsetenv("DUMP_HOST")
+
+	parser := env.NewParser().WithResolver("ssm", func(ctx context.Context, ref string) (string, error) {
+		return "tok-secret", nil
+	})
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, ok := os.LookupEnv("DUMP_TOKEN"); ok || cfg.Token != "tok-secret" {
+		t.Fatalf("expected the resolved token and the variable to be unset, got %+v", cfg)
+	}
+
+	dump := parser.Dump(&cfg)
+	if strings.Contains(dump, "tok-secret") || !strings.Contains(dump, "Token: ***") || !strings.Contains(dump, "Host: db") {
+		t.Errorf("expected the resolved value to be masked, got:\n%s", dump)
+	}
+}
@@ -1,13 +1,22 @@
 package env
 
 import (
+	"context"
+	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math/big"
 	"os"
 	"reflect"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/igwtcode/go-env/internal/topt"
 )
@@ -15,6 +24,14 @@ import (
 const (
 	DefaultTagOptionSeparator  = "," // Default separator for tag options
 	DefaultSliceValueSeparator = "|" // Default separator for slice values
+
+	FileVariantSuffix = "_FILE" // Suffix of the variables naming files that hold values, with WithFileVariants
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 )
 
 // Parser represents a configurable environment variable parser.
@@ -22,14 +39,95 @@ type Parser struct {
 	TagOptionSeparator  string // Separator for options in the tag (e.g., ',')
 	SliceValueSeparator string // Separator for values in slices (e.g., '|')
 	NamePrefix          string // Name prefix for environment variables
+
+	ExecAllowlist []string      // Commands allowed to run for `exec:` values (empty disables exec values)
+	ExecTimeout   time.Duration // Timeout for `exec:` commands (default: 5s)
+
+	Converters map[reflect.Type]ConverterFunc // Custom converters for field types
+	Validators map[string]func(string) error  // Custom validators by tag option name (e.g., v_port_range)
+	Resolvers  map[string]ResolverFunc        // Resolvers of reference values by scheme (e.g., ssm for ssm://...)
+	Decryptor  DecryptFunc                    // Decrypts `enc:` values (e.g., with KMS)
+
+	LegacyPrefix string           // Former name prefix still accepted as a fallback for NamePrefix
+	WarnFunc     func(msg string) // Receives warnings, e.g., about legacy variable names
+
+	ResolvePolicy ResolvePolicy // Chooses among several set names of a field (default: FirstWins)
+
+	Groups map[string]GroupConstraint // Constraints on the number of set fields per group
+
+	Coverage *Coverage // Records which fields were resolved from environment variables (for tests)
+
+	Expand bool // Expands ${VAR} references in all values, as the `expand` option does per field
+
+	Interpolate bool // Substitutes references to other fields ({{.Field}}) in all values, as the `interpolate` option does per field
+
+	EmptyIsSet bool // Treats variables set to an empty value as provided, so their defaults are not used
+
+	KeepExisting bool // Keeps non-zero field values when no variable is set, as the `keep` option does per field
+
+	AllRequired bool // Requires every field without a default, unless it has the `optional` option
+
+	ExactNameMatch    bool // Disables the field name fallbacks, as the `exact` option does per field
+	ExplicitNamesOnly bool // Binds only fields with the `name` option, never deriving names from field names
+	SnakeCaseNames    bool // Derives names from field names in SNAKE_CASE (e.g., MaxRetryCount to MAX_RETRY_COUNT)
+	AutoPrefix        bool // Prefixes the names of nested struct fields with the struct's field name (e.g., DATABASE_)
+
+	CaarlosTags   bool // Reads caarlos0/env style tags (env:"NAME,required" with envDefault, envSeparator and envPrefix)
+	EnvconfigTags bool // Reads kelseyhightower/envconfig style tags (envconfig, default, required, split_words, ignored)
+
+	FileVariants bool // Reads values from the files named by <NAME>_FILE variables when <NAME> is unset (Docker secrets)
+
+	Lookup func(key string) (string, bool) // Looks up variables instead of the process environment (default: os.LookupEnv)
+
+	DotenvFiles []string // Dotenv files merged beneath the environment variables, later files taking precedence
+
+	StrictUnknown bool // Fails on variables with the name prefix that no field reads (e.g., typos)
+	StrictTags    bool // Fails on exported fields without an `env` tag, other than nested structs
+
+	StrictDuplicates bool // Fails when two fields read the same variable, e.g., after copying a tag
+
+	Trace  io.Writer    // Receives a log of how each field is resolved, for debugging
+	Logger *slog.Logger // Receives structured events about each field and Unmarshal call
+
+	FieldHook   FieldHook   // Observes or rewrites values before they are validated and converted
+	PostSetHook PostSetHook // Observes values once they are set
+
+	dotenv    map[string]string // Variables loaded from the dotenv files for the current call
+	source    map[string]string // Variables of the map source of UnmarshalFromMap, for the unknown variables check
+	overrides map[string]string // Values of UnmarshalWithOverrides by variable name, taking precedence over all sources
+	secrets   *secretFields     // Fields read from secret sources, by struct type, for Dump
 }
 
-// NewParser creates a new Parser with default configuration.
-func NewParser() *Parser {
-	return &Parser{
+// Option configures a Parser, e.g., func(p *env.Parser) { p.WithNamePrefix("APP_") }.
+type Option func(p *Parser)
+
+// NewParser creates a new Parser with default configuration, applying the given options in order.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
 		TagOptionSeparator:  DefaultTagOptionSeparator,
 		SliceValueSeparator: DefaultSliceValueSeparator,
+		secrets:             &secretFields{},
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
+}
+
+// Clone returns a copy of the parser that can be configured independently, e.g., to specialize a shared base
+// parser per subsystem. Maps and slices are copied; functions and the coverage recorder are shared.
+//
+// Unmarshal and the other read-only methods are safe for concurrent use, as long as the parser is not
+// configured at the same time. Configure clones instead of a parser in use.
+func (p *Parser) Clone() *Parser {
+	q := *p
+	q.ExecAllowlist = slices.Clone(p.ExecAllowlist)
+	q.DotenvFiles = slices.Clone(p.DotenvFiles)
+	q.Converters = maps.Clone(p.Converters)
+	q.Validators = maps.Clone(p.Validators)
+	q.Resolvers = maps.Clone(p.Resolvers)
+	q.Groups = maps.Clone(p.Groups)
+	return &q
 }
 
 // WithTagOptionSeparator configures the separator for tag options (default: ',').
@@ -56,6 +154,191 @@ func (p *Parser) WithNamePrefix(prefix string) *Parser {
 	return p
 }
 
+// WithLegacyPrefix configures a former name prefix whose variables are still read when the
+// variables with the current prefix are unset. Every use is reported through the warning function.
+func (p *Parser) WithLegacyPrefix(prefix string) *Parser {
+	p.LegacyPrefix = prefix
+	return p
+}
+
+// WithWarnFunc configures the function receiving warnings, such as uses of legacy variable names.
+func (p *Parser) WithWarnFunc(fn func(msg string)) *Parser {
+	p.WarnFunc = fn
+	return p
+}
+
+// WithResolvePolicy configures how to choose the value when several names of a field are set (default: FirstWins).
+func (p *Parser) WithResolvePolicy(policy ResolvePolicy) *Parser {
+	p.ResolvePolicy = policy
+	return p
+}
+
+// WithGroup constrains how many fields of a group (set with the `group` option) may be set.
+// For example, WithGroup("listeners", 1, 1) requires exactly one of them, WithGroup("listeners", 0, 0) none
+// and WithGroup("listeners", 1, NoGroupLimit) at least one.
+func (p *Parser) WithGroup(name string, min, max int) *Parser {
+	if p.Groups == nil {
+		p.Groups = map[string]GroupConstraint{}
+	}
+	p.Groups[name] = GroupConstraint{Min: min, Max: max}
+	return p
+}
+
+// WithCoverage records in c which fields are resolved from environment variables, so tests can
+// assert that every config field is exercised.
+func (p *Parser) WithCoverage(c *Coverage) *Parser {
+	p.Coverage = c
+	return p
+}
+
+// WithExpand configures whether ${VAR} and $VAR references are expanded in all values,
+// including defaults. The `expand` option enables expansion for a single field.
+func (p *Parser) WithExpand(enabled bool) *Parser {
+	p.Expand = enabled
+	return p
+}
+
+// WithEmptyIsSet configures whether variables explicitly set to an empty value count as provided.
+// When enabled, `NAME=` sets the field to its zero value instead of using the default.
+func (p *Parser) WithEmptyIsSet(enabled bool) *Parser {
+	p.EmptyIsSet = enabled
+	return p
+}
+
+// WithKeepExisting configures whether non-zero values already present in the struct are kept when no
+// variable is set, taking precedence over defaults. This allows layering variables on top of values
+// loaded from files or flags. The `keep` option enables this for a single field.
+func (p *Parser) WithKeepExisting(enabled bool) *Parser {
+	p.KeepExisting = enabled
+	return p
+}
+
+// WithAllRequired makes every field without a `default` (or another way to get a value from its tag, such as a
+// resolver reference) required, so no field is silently left at its zero value. Fields with the `optional`
+// option opt out.
+func (p *Parser) WithAllRequired() *Parser {
+	p.AllRequired = true
+	return p
+}
+
+// isRequired reports whether a field must have a value: it has the `required` option, or all fields without a
+// default are required.
+func (p *Parser) isRequired(tagOptions map[string]string) bool {
+	if _, required := tagOptions[topt.REQUIRED]; required {
+		return true
+	}
+	if !p.AllRequired {
+		return false
+	}
+	if _, optional := tagOptions[topt.OPTIONAL]; optional {
+		return false
+	}
+	return tagOptions[topt.DEFAULT] == "" && tagOptions[topt.DEFAULT_IF] == "" && p.tagReference(tagOptions) == ""
+}
+
+// WithExactNameMatch disables the automatic lookups of the field name in upper and lower case. Fields with
+// the `name` option only read the listed names, other fields only read the field name as is.
+func (p *Parser) WithExactNameMatch() *Parser {
+	p.ExactNameMatch = true
+	return p
+}
+
+// WithExplicitNamesOnly reads only the names listed in the `name` option. Fields without it are not bound to
+// any variable (as if they had no `env` tag), so they never pick up variables that happen to match the field name.
+// Nested structs are still populated.
+func (p *Parser) WithExplicitNamesOnly() *Parser {
+	p.ExplicitNamesOnly = true
+	return p
+}
+
+// WithSnakeCaseNames derives the names of fields without the `name` option from their field names in
+// upper SNAKE_CASE (e.g., MaxRetryCount reads MAX_RETRY_COUNT), instead of the field name in its original,
+// upper and lower case.
+func (p *Parser) WithSnakeCaseNames() *Parser {
+	p.SnakeCaseNames = true
+	return p
+}
+
+// WithAutoPrefix prefixes the variables of nested structs with their field name in upper case and an underscore
+// (e.g., Config.Database.Host reads DATABASE_HOST), after the name prefix and the prefixes of enclosing structs.
+// Embedded structs and structs with the `prefix` or `flatten` option are not prefixed automatically.
+func (p *Parser) WithAutoPrefix() *Parser {
+	p.AutoPrefix = true
+	return p
+}
+
+// WithFileVariants configures whether the values of all fields can be given as files: when none of a field's
+// variables is set, the file named by the first set <NAME>_FILE variable is read instead, following the Docker
+// secrets convention (e.g., DB_PASSWORD_FILE=/run/secrets/db_password). Values read from files are masked.
+func (p *Parser) WithFileVariants(enabled bool) *Parser {
+	p.FileVariants = enabled
+	return p
+}
+
+// WithLookup configures the function used to look up variables instead of os.LookupEnv, so values can
+// come from any source (e.g., a map in tests, a snapshot or a remote key/value store). All lookups,
+// including `expand` references and conditions, go through it.
+func (p *Parser) WithLookup(fn func(key string) (string, bool)) *Parser {
+	p.Lookup = fn
+	return p
+}
+
+// WithStrictUnknown makes Unmarshal fail when variables starting with the name prefix are not read by any
+// field, catching typos such as APP_TIMEOUTT that are otherwise silently ignored. It requires a name prefix.
+// Variables referenced by `defaultenv`, `expand` and conditions count as read. The variables of WithLookup
+// and WithSources cannot be listed, so Unmarshal fails when they are combined with this check.
+func (p *Parser) WithStrictUnknown() *Parser {
+	p.StrictUnknown = true
+	return p
+}
+
+// WithStrictTags makes Unmarshal fail on exported fields without an `env` tag, so every setting must be declared
+// explicitly. Fields tagged with "-" are ignored, and nested structs only need tags on their own fields.
+func (p *Parser) WithStrictTags() *Parser {
+	p.StrictTags = true
+	return p
+}
+
+// WithStrictDuplicates makes Unmarshal fail before reading any variable when two fields read the same variable
+// (after prefixing), which usually means a tag was copied without being changed. The names of the `name` option,
+// or else the first name derived from the field name, are compared.
+func (p *Parser) WithStrictDuplicates() *Parser {
+	p.StrictDuplicates = true
+	return p
+}
+
+// WithExecAllowlist enables `exec:` values and configures the commands they may run.
+func (p *Parser) WithExecAllowlist(commands ...string) *Parser {
+	p.ExecAllowlist = commands
+	return p
+}
+
+// WithExecTimeout configures how long an `exec:` command may run (default: 5s).
+func (p *Parser) WithExecTimeout(timeout time.Duration) *Parser {
+	p.ExecTimeout = timeout
+	return p
+}
+
+// WithConverter registers a converter for the type of the given sample value (e.g., `MyType{}`).
+func (p *Parser) WithConverter(sample interface{}, fn ConverterFunc) *Parser {
+	if p.Converters == nil {
+		p.Converters = map[reflect.Type]ConverterFunc{}
+	}
+	p.Converters[reflect.TypeOf(sample)] = fn
+	return p
+}
+
+// RegisterValidator registers a validator referenced from tags by name (e.g., `env:"v_port_range"`), like the
+// built-in `v_*` options. It is applied to the value of the field, or to each element of slices, and the value
+// is rejected when it returns an error. Names are case-insensitive.
+func (p *Parser) RegisterValidator(name string, fn func(string) error) *Parser {
+	if p.Validators == nil {
+		p.Validators = map[string]func(string) error{}
+	}
+	p.Validators[strings.ToLower(strings.TrimSpace(name))] = fn
+	return p
+}
+
 // parseTag parses the tag string into a map of options (e.g., "required", "default=foo").
 func (p *Parser) parseTag(tag string) map[string]string {
 	options := map[string]string{}
@@ -74,84 +357,665 @@ func (p *Parser) parseTag(tag string) map[string]string {
 
 // Unmarshal reads environment variables and populates the struct fields.
 func (p *Parser) Unmarshal(envStruct interface{}) error {
+	st := &decodeState{}
+	return p.decode(envStruct, st)
+}
+
+// Parse populates a new value of type T (a struct type) from environment variables, using a parser
+// configured with the given options.
+func Parse[T any](opts ...Option) (T, error) {
+	var v T
+	err := NewParser(opts...).Unmarshal(&v)
+	return v, err
+}
+
+// MustParse is like Parse but panics if the value cannot be populated. It simplifies the initialization
+// of global configuration.
+func MustParse[T any](opts ...Option) T {
+	v, err := Parse[T](opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// UnmarshalContext populates the struct like Unmarshal, stopping with the context's error once it is canceled
+// or its deadline passes. The context is passed on to `exec:` commands.
+func (p *Parser) UnmarshalContext(ctx context.Context, envStruct interface{}) error {
+	st := &decodeState{ctx: ctx}
+	return p.decode(envStruct, st)
+}
+
+// UnmarshalAll populates the struct like Unmarshal, but keeps going after invalid or missing variables and
+// returns all errors joined together (see errors.Join), so they can be fixed at once.
+func (p *Parser) UnmarshalAll(envStruct interface{}) error {
+	st := &decodeState{collectAll: true}
+	return p.decode(envStruct, st)
+}
+
+// Validate checks the environment against the struct like UnmarshalAll, resolving, converting and validating
+// every field, but populates a deep copy and leaves envStruct unchanged, including the maps, slices and pointers
+// it holds. Variables with the `unset` option are not removed and no coverage is recorded, so preflight checks
+// can verify an environment before the process starts.
+func (p *Parser) Validate(envStruct interface{}) error {
 	v := reflect.ValueOf(envStruct).Elem()
-	t := reflect.TypeOf(envStruct).Elem()
+	dry := reflect.New(v.Type())
+	deepCopy(dry.Elem(), v)
+	st := &decodeState{collectAll: true, dryRun: true}
+	return p.decode(dry.Interface(), st)
+}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		fieldValue := v.Field(i)
+// Reload populates a copy of the struct from the current environment and, when it succeeds, assigns the copy
+// to envStruct in a single step, returning the paths of the fields whose values changed (e.g., on SIGHUP).
+// On error, envStruct is left unchanged. Readers of the struct must be synchronized with Reload by the caller.
+func (p *Parser) Reload(envStruct interface{}) (changed []string, err error) {
+	v := reflect.ValueOf(envStruct).Elem()
+	next := reflect.New(v.Type())
+	deepCopy(next.Elem(), v)
+	if err := p.decode(next.Interface(), &decodeState{}); err != nil {
+		return nil, err
+	}
 
-		// Skip unexported fields
-		if !fieldValue.CanSet() {
-			continue
+	for _, f := range p.fields(v.Type()) {
+		if !reflect.DeepEqual(v.FieldByIndex(f.index).Interface(), next.Elem().FieldByIndex(f.index).Interface()) {
+			changed = append(changed, f.path)
 		}
+	}
+	v.Set(next.Elem())
+	return changed, nil
+}
 
-		// Recursively handle embedded structs
-		if fieldValue.Kind() == reflect.Struct {
-			if err := p.Unmarshal(fieldValue.Addr().Interface()); err != nil {
-				return err
+// deepCopy copies src to dst, duplicating the maps, slices and pointers it holds so that populating dst
+// leaves src unchanged. Unexported struct fields are copied as is.
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		elem := reflect.New(src.Type().Elem())
+		deepCopy(elem.Elem(), src.Elem())
+		dst.Set(elem)
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		deepCopy(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		for iter := src.MapRange(); iter.Next(); {
+			elem := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(elem, iter.Value())
+			m.SetMapIndex(iter.Key(), elem)
+		}
+		dst.Set(m)
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i))
 			}
-			continue
 		}
+	default:
+		dst.Set(src)
+	}
+}
 
-		// Parse the `env` tagVal for environment variable options
-		tagVal, tagOk := field.Tag.Lookup("env")
-		if !tagOk {
-			continue
+// ApplyDefaults sets the fields of the struct with a `default` option to that value, converted and validated like
+// values read from variables, without reading the environment or dotenv files. Other fields are left unchanged,
+// and requirements and cross-field validation are not checked, so tests and tools can build baseline
+// configurations deterministically.
+func (p *Parser) ApplyDefaults(envStruct interface{}) error {
+	q := *p
+	q.DotenvFiles = nil
+	q.source = nil
+	q.Lookup = func(string) (string, bool) { return "", false }
+	return q.decode(envStruct, &decodeState{defaultsOnly: true})
+}
+
+// UnmarshalFromMap populates the struct from the key/value pairs of src instead of the environment, with the
+// same tag semantics (e.g., for tests, parsed files or API responses).
+func (p *Parser) UnmarshalFromMap(src map[string]string, envStruct interface{}) error {
+	q := *p
+	q.source = src
+	q.Lookup = func(key string) (string, bool) {
+		val, ok := src[key]
+		return val, ok
+	}
+	return q.Unmarshal(envStruct)
+}
+
+// UnmarshalWithOverrides populates the struct like Unmarshal, with values that take precedence over the
+// environment and the other sources, e.g., from command-line arguments or tests. Keys are field paths (e.g.,
+// Database.Port), overriding all names of the field, or variable names. The process environment is not changed.
+func (p *Parser) UnmarshalWithOverrides(envStruct interface{}, overrides map[string]string) error {
+	q := *p
+	q.overrides = make(map[string]string, len(overrides))
+	paths := map[string][]string{}
+	for _, f := range p.fields(reflect.TypeOf(envStruct).Elem()) {
+		paths[f.path] = p.names(f)
+	}
+	for key, val := range overrides {
+		names, ok := paths[key]
+		if !ok {
+			names = []string{key}
+		}
+		for _, name := range names {
+			q.overrides[name] = val
 		}
-		tagOptions := p.parseTag(tagVal)
+	}
+	return q.Unmarshal(envStruct)
+}
 
-		// Get the lookup order for environment variables, ensuring unique names
-		envNames := getEnvNames(field.Name, tagOptions, p)
-		envVal := getEnvValue(envNames)
+// UnmarshalFromEnviron populates the struct from an environ-style list of KEY=VALUE entries (e.g., os.Environ()
+// or exec.Cmd.Env) instead of the environment. As with exec.Cmd.Env, the last entry of a duplicated key wins.
+// Entries without '=' are ignored.
+func (p *Parser) UnmarshalFromEnviron(environ []string, envStruct interface{}) error {
+	src := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, val, ok := strings.Cut(kv, "="); ok {
+			src[key] = val
+		}
+	}
+	return p.UnmarshalFromMap(src, envStruct)
+}
 
-		// Apply trim by default, can be disabled with 'notrim' option
-		if _, notrim := tagOptions[topt.NOTRIM]; !notrim {
-			envVal = strings.TrimSpace(envVal)
+// decode populates the struct using the given state and runs the checks that need all fields to be resolved.
+func (p *Parser) decode(envStruct interface{}, st *decodeState) (err error) {
+	if len(p.DotenvFiles) > 0 && p.dotenv == nil {
+		vals, err := loadDotenvFiles(p.DotenvFiles)
+		if err != nil {
+			return err
+		}
+		q := *p
+		q.dotenv = vals
+		return q.decode(envStruct, st)
+	}
+	v := reflect.ValueOf(envStruct).Elem()
+	st.root = v.Type().Name()
+	st.typ = v.Type()
+	st.rootValue = v
+	if st.ctx == nil {
+		st.ctx = context.Background()
+	}
+	if p.Logger != nil {
+		start := time.Now()
+		defer func() { p.logDecode(st, time.Since(start), err) }()
+	}
+	if err := p.checkDuplicates(st); err != nil {
+		return err
+	}
+	if err := p.unmarshal(v, "", "", st); err != nil {
+		return err
+	}
+	if err := p.resolvePending(st); err != nil {
+		return err
+	}
+	if st.defaultsOnly {
+		return errors.Join(st.errs...)
+	}
+	for _, check := range []func(*decodeState) error{p.checkRequiredIf, p.checkGroups, (*decodeState).checkXor, p.checkUnknown} {
+		if err := st.fail(check(st)); err != nil {
+			return err
+		}
+	}
+	if len(st.errs) > 0 {
+		return errors.Join(st.errs...)
+	}
+	if err := p.validateStructs(v, "", "", st); err != nil {
+		return err
+	}
+	if st.dryRun {
+		return nil
+	}
+	if p.Coverage != nil {
+		p.Coverage.record(v.Type(), st.records)
+	}
+	if p.secrets != nil {
+		p.secrets.record(v.Type(), st.records)
+	}
+	if p.Lookup == nil {
+		for _, name := range st.unset {
+			os.Unsetenv(name)
 		}
+	}
+	return nil
+}
 
-		// Handle default value
-		if envVal == "" && tagOptions[topt.DEFAULT] != "" {
-			envVal = tagOptions[topt.DEFAULT]
+// decodeState holds the state of a single Unmarshal call across nested structs.
+type decodeState struct {
+	groups       map[string][]string     // Names of the set variables per field group
+	xor          map[string][]string     // Names of the set variables per mutually exclusive group
+	unset        []string                // Variables to remove from the environment after a successful decode
+	records      []fieldRecord           // How each field was resolved
+	requiredIf   []requiredIfCheck       // Conditional requirements to check once all fields are resolved
+	root         string                  // Name of the top-level struct type, used in error messages
+	typ          reflect.Type            // Top-level struct type
+	ctx          context.Context         // Context of the call, checked before each field
+	collectAll   bool                    // Whether to collect all errors instead of stopping at the first one
+	errs         []error                 // Errors collected so far when collecting all errors
+	dryRun       bool                    // Whether the call only validates, without side effects
+	defaultsOnly bool                    // Whether only defaults are applied, without reading variables or checking requirements
+	rootValue    reflect.Value           // Top-level struct value, for references to other fields
+	pending      map[string]pendingField // Fields with references to other fields, by path
+	pendingOrder []string                // Paths of the pending fields in the order they were found
+	refStates    map[string]int          // Interpolation states of the pending fields, once all other fields are populated
+	refChain     []string                // Paths of the pending fields being resolved, for cycle errors
+	referenced   map[string]bool         // Variables read other than through field names (e.g., by `expand`), for the unknown variables check
+}
+
+// reference records a variable read other than through a field's names, e.g., a `defaultenv` fallback.
+func (st *decodeState) reference(name string) {
+	if st.referenced == nil {
+		st.referenced = map[string]bool{}
+	}
+	st.referenced[name] = true
+}
+
+// fail returns the error, or records it and returns nil when collecting all errors.
+func (st *decodeState) fail(err error) error {
+	if err != nil && st.collectAll {
+		st.errs = append(st.errs, err)
+		return nil
+	}
+	return err
+}
+
+// unmarshal populates the fields of a struct value, adding the given prefix to the environment variable names.
+// The path is the dotted path of the struct value's field within the top-level struct.
+func (p *Parser) unmarshal(v reflect.Value, prefix string, path string, st *decodeState) error {
+	t := v.Type()
+	meta := p.structMeta(t, prefix)
+
+	for i := 0; i < v.NumField(); i++ {
+		if err := st.ctx.Err(); err != nil {
+			return err
+		}
+		if err := st.fail(p.unmarshalField(t.Field(i), v.Field(i), meta[i], prefix, path, st)); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// Handle required fields
-		if _, required := tagOptions[topt.REQUIRED]; required && envVal == "" {
-			return fmt.Errorf("environment variable %s is required but not set", strings.Join(envNames, p.SliceValueSeparator))
+// unmarshalField populates a single field of a struct value.
+func (p *Parser) unmarshalField(field reflect.StructField, fieldValue reflect.Value, meta fieldMeta, prefix string, path string, st *decodeState) (err error) {
+	fieldPath := joinPath(path, field.Name)
+
+	// Skip unexported fields
+	if !fieldValue.CanSet() {
+		return nil
+	}
+
+	// Use the parsed `env` tag options, skipping fields tagged with "-"
+	if meta.skip {
+		return nil
+	}
+	tagOk, tagOptions := meta.tagOk, meta.tagOptions
+	_, isJSON := tagOptions[topt.JSON]
+
+	// Recursively handle nested and embedded structs, unless decoded from JSON
+	if fieldValue.Kind() == reflect.Struct && !p.isLeafType(fieldValue.Type()) && !isJSON {
+		nestedPrefix := p.nestedPrefix(field, tagOptions, prefix)
+		if err := p.unmarshal(fieldValue, nestedPrefix, fieldPath, st); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if !tagOk {
+		if _, tagged := field.Tag.Lookup("env"); p.StrictTags && !tagged {
+			return fmt.Errorf("%s: exported field has no env tag (tag it with `env:\"-\"` to ignore it)", describeField(st.root, fieldPath, ""))
 		}
+		return nil
+	}
+
+	// Trace and log the outcome of the field, when enabled
+	var deferred bool
+	if p.Trace != nil || p.Logger != nil {
+		start := time.Now()
+		defer func() {
+			if deferred {
+				return
+			}
+			if err != nil {
+				p.tracef(st, fieldPath, "failed: %v", err)
+			} else {
+				p.tracef(st, fieldPath, "set")
+			}
+			p.logField(st, fieldPath, time.Since(start), err)
+		}()
+	}
 
-		// Handle lowercase
-		if _, lower := tagOptions[topt.LOWER]; lower {
-			envVal = strings.ToLower(envVal)
+	// Get the lookup order for environment variables, copied as the names end up in errors
+	envNames := slices.Clone(meta.names)
+	envName, envVal, ignored, err := p.getEnvValue(envNames)
+	if err != nil {
+		return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, ""), err)
+	}
+	p.traceLookup(st, fieldPath, envNames, envName)
+
+	// Prefer the replacement of a deprecated variable, and warn when only the deprecated one is set
+	var replacement string
+	if dep := tagOptions[topt.DEPRECATED]; dep != "" {
+		newName := p.NamePrefix + prefix + dep
+		name, val, _, err := p.getEnvValue([]string{newName})
+		if err != nil {
+			return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, ""), err)
+		}
+		if name != "" {
+			if envName != "" {
+				ignored = append([]string{envName}, ignored...)
+			}
+			envName, envVal = name, val
+			p.tracef(st, fieldPath, "using %s, the replacement of deprecated %s", name, dep)
+		} else if envName != "" {
+			replacement = newName
+			p.warn("environment variable %s is deprecated, rename it to %s", envName, newName)
 		}
+		envNames = append([]string{newName}, envNames...)
+	}
 
-		// Handle uppercase
-		if _, upper := tagOptions[topt.UPPER]; upper {
-			envVal = strings.ToUpper(envVal)
+	// Read the value from the file named by a <NAME>_FILE variable (the Docker secrets convention), when enabled
+	var fromFile bool
+	if p.FileVariants && envName == "" {
+		name, val, err := p.readFileVariant(envNames)
+		if err != nil {
+			return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, name), err)
+		}
+		if name != "" {
+			envName, envVal, fromFile = name, val, true
+			p.tracef(st, fieldPath, "read value from the file in %s", name)
 		}
+	}
 
-		// Process slices using the configured slice value separator
-		if fieldValue.Kind() == reflect.Slice {
-			if err := handleSliceWithSeparator(fieldValue, envVal, tagOptions, p.SliceValueSeparator); err != nil {
-				return err
+	// Fall back to other variables (used as is, without prefixes) before the default
+	if fallback := tagOptions[topt.DEFAULTENV]; envName == "" && fallback != "" {
+		for _, name := range strings.Split(fallback, p.SliceValueSeparator) {
+			name = strings.TrimSpace(name)
+			st.reference(name)
+			if val, ok := p.lookupSet(name); ok {
+				envName, envVal = name, val
+				p.tracef(st, fieldPath, "falling back to %s", envName)
+				break
 			}
-			continue
 		}
+	}
 
-		// Check if the field has an AWS-specific validation option and apply the validation
-		if err := checkForAwsValidation(field.Name, envVal, tagOptions); err != nil {
-			return err
+	// Record the field as set for its groups
+	if groups, ok := tagOptions[topt.GROUP]; ok && envVal != "" {
+		st.addToGroups(strings.Split(groups, p.SliceValueSeparator), envName)
+	}
+	if xor, ok := tagOptions[topt.XOR]; ok && envVal != "" {
+		st.addToXor(strings.Split(xor, p.SliceValueSeparator), envName)
+	}
+
+	// Strip byte-order marks and carriage returns left over from files edited on Windows
+	envVal = stripBOMAndCR(envVal)
+	rawVal := envVal
+
+	// Apply trim by default, can be disabled with 'notrim' option
+	if _, notrim := tagOptions[topt.NOTRIM]; !notrim {
+		if trimmed := strings.TrimSpace(envVal); trimmed != envVal {
+			p.tracef(st, fieldPath, "trimmed whitespace")
+			envVal = trimmed
+		}
+	}
+
+	// Handle variables that are set but empty, when they must not be
+	if _, notempty := tagOptions[topt.NOTEMPTY]; notempty && strings.TrimSpace(envVal) == "" {
+		if name := p.setButEmpty(envNames); name != "" {
+			return &ValidationError{Field: fieldPath, Names: envNames, Var: name, Err: errors.New("variable is set but empty"), root: st.root}
+		}
+	}
+
+	// Handle default value
+	// Variables explicitly set to an empty value skip the default when the parser's EmptyIsSet option is enabled
+	provided := envName != "" && (envVal != "" || p.EmptyIsSet)
+	rec := fieldRecord{Path: fieldPath, Name: envName, Source: SourceEnv, Masked: fromFile, Replacement: replacement, Ignored: ignored}
+	if !provided {
+		if def, ok := p.conditionalDefault(st, path, tagOptions[topt.DEFAULT_IF]); ok && def != "" {
+			envVal = def
+			rec.Source = SourceDefault
+			p.tracef(st, fieldPath, "using the conditional default")
+		} else if ref := p.tagReference(tagOptions); ref != "" {
+			envVal = ref
+			rec.Source = SourceDefault
+			p.tracef(st, fieldPath, "using the tag reference")
+		} else if tagOptions[topt.DEFAULT] != "" {
+			envVal = tagOptions[topt.DEFAULT]
+			rec.Source = SourceDefault
+			p.tracef(st, fieldPath, "using the default")
 		}
+	}
+	if envVal == "" && !provided {
+		rec.Source = SourceNone
+	}
+	if rec.Name == "" {
+		rec.Name = envNames[0]
+	}
+
+	// Keep a value already present in the struct (e.g., loaded from a file) when no variable is set
+	if _, keep := tagOptions[topt.KEEP]; !provided && (keep || p.KeepExisting) && !fieldValue.IsZero() {
+		_, sensitive := tagOptions[topt.SENSITIVE]
+		rec.Source, rec.Value, rec.Masked = SourceExisting, fmt.Sprint(fieldValue.Interface()), sensitive
+		st.records = append(st.records, rec)
+		p.tracef(st, fieldPath, "keeping the existing value %s", rec.traceValue())
+		return nil
+	}
 
-		// Set value to the appropriate field
-		if err := setValue(fieldValue, envVal, tagOptions); err != nil {
+	// Populate fields referencing other fields ({{.Field}}) once all other fields are, then substitute the references
+	if p.interpolates(tagOptions, envVal) {
+		if st.refStates == nil {
+			st.deferField(pendingField{field: field, value: fieldValue, meta: meta, prefix: prefix, path: path})
+			deferred = true
+			p.tracef(st, fieldPath, "deferred until the referenced fields are populated")
+			return nil
+		}
+		val, err := p.interpolate(st, fieldPath, envName, envVal)
+		if err != nil {
 			return err
 		}
+		envVal = val
+		p.tracef(st, fieldPath, "substituted field references")
+	}
+
+	// Leave fields without a default unchanged when only applying defaults
+	if st.defaultsOnly && rec.Source == SourceNone {
+		return nil
+	}
+
+	// Expand ${VAR} and $VAR references, when enabled on the parser or the field
+	if _, expand := tagOptions[topt.EXPAND]; expand || p.Expand {
+		envVal = os.Expand(envVal, func(name string) string {
+			st.reference(name)
+			return p.getenv(name)
+		})
+		p.tracef(st, fieldPath, "expanded variable references")
+	}
+
+	// Run the command for `exec:` values, when enabled on the parser
+	if p.isExecValue(envVal) {
+		rec.Masked = true
+		out, err := p.runExecValue(st.ctx, envVal)
+		if err != nil {
+			return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, envName), err)
+		}
+		envVal = out
+		p.tracef(st, fieldPath, "ran exec command")
+		if _, notrim := tagOptions[topt.NOTRIM]; !notrim {
+			envVal = strings.TrimSpace(envVal)
+		}
+	}
+
+	// Resolve references to remote values (e.g., ssm:///app/db_password) with the registered resolvers
+	if scheme, resolve, ref := p.resolver(envVal); resolve != nil {
+		rec.Masked = true
+		out, err := resolve(st.ctx, ref)
+		if err != nil {
+			return fmt.Errorf("%s: resolving %s reference: %w", describeField(st.root, fieldPath, envName), scheme, err)
+		}
+		envVal = out
+		p.tracef(st, fieldPath, "resolved %s reference", scheme)
+	}
+
+	// Decrypt `enc:` values, when a decryptor is configured or the field must be encrypted
+	_, encrypted := tagOptions[topt.ENCRYPTED]
+	if strings.HasPrefix(envVal, EncryptedValuePrefix) && (p.Decryptor != nil || encrypted) {
+		rec.Masked = true
+		out, err := p.decryptValue(st.ctx, envVal)
+		if err != nil {
+			return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, envName), err)
+		}
+		envVal = out
+		p.tracef(st, fieldPath, "decrypted value")
+	} else if encrypted && envVal != "" {
+		return &ValidationError{Field: fieldPath, Names: envNames, Var: envName, Err: errors.New("value must be encrypted (enc:...)"), root: st.root}
+	}
+
+	// Read the value from the file at the given path (e.g., Docker and Kubernetes secrets)
+	if _, file := tagOptions[topt.FILE]; file && envVal != "" {
+		rec.Masked = true
+		content, err := os.ReadFile(envVal)
+		if err != nil {
+			return fmt.Errorf("%s: reading value from file: %w", describeField(st.root, fieldPath, envName), err)
+		}
+		envVal = stripBOMAndCR(strings.TrimRight(string(content), "\n"))
+		p.tracef(st, fieldPath, "read value from file")
+		if _, notrim := tagOptions[topt.NOTRIM]; !notrim {
+			envVal = strings.TrimSpace(envVal)
+		}
+	}
+
+	// Let the field hook observe or rewrite the value
+	if p.FieldHook != nil {
+		val, err := p.FieldHook(st.hookInfo(field, fieldPath, envNames, rec), envVal)
+		if err != nil {
+			return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, envName), err)
+		}
+		if val != envVal {
+			p.tracef(st, fieldPath, "field hook rewrote the value")
+		}
+		envVal = val
+	}
+
+	// Handle required fields
+	if p.isRequired(tagOptions) && envVal == "" && !st.defaultsOnly {
+		return &RequiredError{Field: fieldPath, Names: envNames, root: st.root, separator: p.SliceValueSeparator}
+	}
+
+	// Handle lowercase
+	if _, lower := tagOptions[topt.LOWER]; lower {
+		envVal = strings.ToLower(envVal)
+		p.tracef(st, fieldPath, "converted to lower case")
+	}
+
+	// Handle uppercase
+	if _, upper := tagOptions[topt.UPPER]; upper {
+		envVal = strings.ToUpper(envVal)
+		p.tracef(st, fieldPath, "converted to upper case")
+	}
+
+	if _, sensitive := tagOptions[topt.SENSITIVE]; sensitive {
+		rec.Masked = true
+	}
+	rec.Value = envVal
+	st.records = append(st.records, rec)
+	p.tracef(st, fieldPath, "value %s from %s", rec.traceValue(), rec.Source)
+
+	// Remove the variables from the environment once all fields are populated
+	if _, unset := tagOptions[topt.UNSET]; unset {
+		st.unset = append(st.unset, envNames...)
+		if envName != "" && !slices.Contains(envNames, envName) {
+			st.unset = append(st.unset, envName)
+		}
+	}
+
+	// Defer conditional requirements until all fields are resolved
+	if cond, ok := tagOptions[topt.REQUIRED_IF]; ok {
+		st.requiredIf = append(st.requiredIf, requiredIfCheck{path: path, fieldPath: fieldPath, names: envNames, cond: cond})
+	}
+
+	// Variables explicitly set to an empty value reset the field to its zero value. Unset fields are left
+	// unchanged with caarlos0/env and envconfig style tags, as those packages do.
+	if provided && envVal == "" {
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+	} else if envVal != "" || !p.compatTags() {
+		if err := p.decodeField(field, fieldValue, envVal, tagOptions); err != nil {
+			// Decode the value, keeping sensitive values and those of secret sources out of error messages
+			return p.fieldError(err, st, fieldPath, envNames, envName, rawVal, envVal, rec.Masked)
+		}
 	}
 
+	// Let the post-set hook observe the value
+	if p.PostSetHook != nil {
+		if err := p.PostSetHook(st.hookInfo(field, fieldPath, envNames, rec), fieldValue.Interface()); err != nil {
+			return fmt.Errorf("%s: %w", describeField(st.root, fieldPath, envName), err)
+		}
+	}
 	return nil
 }
 
+// decodeField validates the resolved value and sets it to the field.
+func (p *Parser) decodeField(field reflect.StructField, fieldValue reflect.Value, envVal string, tagOptions map[string]string) error {
+	// Decode JSON values into any type (e.g., structs, maps or slices of structs)
+	if _, isJSON := tagOptions[topt.JSON]; isJSON {
+		if envVal == "" {
+			return nil
+		}
+		// Decode into a new value, so maps are replaced rather than merged with the keys they already hold
+		decoded := reflect.New(fieldValue.Type())
+		if err := json.Unmarshal([]byte(envVal), decoded.Interface()); err != nil {
+			return fmt.Errorf("invalid JSON value: %w", err)
+		}
+		fieldValue.Set(decoded.Elem())
+		return nil
+	}
+
+	// Process slices using the field's separator or the configured slice value separator
+	if fieldValue.Kind() == reflect.Slice && !p.isLeafType(fieldValue.Type()) {
+		return p.handleSliceWithSeparator(fieldValue, envVal, tagOptions, p.sliceSeparator(tagOptions))
+	}
+
+	// Apply the custom trim options (slices apply them to each element)
+	envVal = trimValue(envVal, tagOptions)
+
+	// Check if the field has an AWS-specific validation option and apply the validation
+	if err := checkForAwsValidation(field.Name, envVal, tagOptions); err != nil {
+		return invalid(err)
+	}
+
+	// Apply the general validation options
+	if err := p.checkForValidation(envVal, tagOptions); err != nil {
+		return invalid(err)
+	}
+
+	// Set value to the appropriate field
+	return p.setValue(fieldValue, envVal, tagOptions)
+}
+
 // awsValidationMap finds and applies the validation function for AWS-specific environment variables tag options.
 func checkForAwsValidation(fieldName string, envVal string, tagOptions map[string]string) error {
 	// if the field is not required and the env value is empty, return
@@ -181,13 +1045,93 @@ func checkForAwsValidation(fieldName string, envVal string, tagOptions map[strin
 	return nil
 }
 
+// checkForValidation applies every general validation option (e.g., v_mac, regex) provided for the field.
+// It is applied to single values and to each element of slices.
+func (p *Parser) checkForValidation(envVal string, tagOptions map[string]string) error {
+	// if the field is not required and the env value is empty, return
+	if _, ok := tagOptions[topt.REQUIRED]; !ok && envVal == "" {
+		return nil
+	}
+
+	for tag, fn := range validationMap {
+		if _, ok := tagOptions[tag]; ok {
+			if err := fn(envVal); err != nil {
+				return err
+			}
+		}
+	}
+
+	if length, ok := tagOptions[topt.LEN]; ok {
+		if err := vLen(envVal, length); err != nil {
+			return err
+		}
+	}
+
+	if layout, ok := tagOptions[topt.V_DATETIME]; ok {
+		if err := vDatetime(envVal, layout); err != nil {
+			return err
+		}
+	}
+
+	if pattern, ok := tagOptions[topt.REGEX]; ok {
+		if err := vRegex(envVal, pattern); err != nil {
+			return err
+		}
+	}
+
+	if allowed, ok := tagOptions[topt.ONEOF]; ok {
+		if err := vOneOf(envVal, strings.Split(allowed, p.SliceValueSeparator)); err != nil {
+			return err
+		}
+	}
+
+	// Apply the custom validators, in name order
+	for _, name := range p.validatorNames() {
+		if _, ok := tagOptions[name]; ok {
+			if err := p.Validators[name](envVal); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validatorNames returns the names of the custom validators in sorted order.
+func (p *Parser) validatorNames() []string {
+	names := make([]string, 0, len(p.Validators))
+	for name := range p.Validators {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// nestedPrefix returns the prefix of the variables of a nested or embedded struct: the `flatten` option drops the
+// prefixes of the enclosing structs, and the `prefix` option (or the field name, with AutoPrefix) is appended.
+func (p *Parser) nestedPrefix(field reflect.StructField, tagOptions map[string]string, prefix string) string {
+	_, flatten := tagOptions[topt.FLATTEN]
+	if flatten {
+		prefix = ""
+	}
+	if own, ok := tagOptions[topt.PREFIX]; ok {
+		return prefix + own
+	}
+	if p.AutoPrefix && !flatten && !field.Anonymous {
+		if p.SnakeCaseNames {
+			return prefix + toSnakeCase(field.Name) + "_"
+		}
+		return prefix + strings.ToUpper(field.Name) + "_"
+	}
+	return prefix
+}
+
 // getEnvNames returns a list of environment variable names to check, based on the 'name' tag option or the field name.
-func getEnvNames(fieldName string, tagOptions map[string]string, p *Parser) []string {
+func getEnvNames(fieldName string, tagOptions map[string]string, p *Parser, prefix string) []string {
 	var envNames []string
 
 	ap := func(sl []string) {
 		for _, s := range sl {
-			v := p.NamePrefix + s
+			v := p.NamePrefix + prefix + s
 			if !slices.Contains(envNames, v) {
 				envNames = append(envNames, v)
 			}
@@ -199,34 +1143,280 @@ func getEnvNames(fieldName string, tagOptions map[string]string, p *Parser) []st
 		ap(strings.Split(name, p.SliceValueSeparator))
 	}
 
-	// Add the field name and the field name in upper and lower case
+	// Never derive names from the field name, if disabled
+	if p.ExplicitNamesOnly {
+		return envNames
+	}
+
+	// Derive the name from the field name in SNAKE_CASE, if enabled
+	if p.SnakeCaseNames {
+		if _, exact := tagOptions[topt.EXACT]; len(envNames) == 0 || !(exact || p.ExactNameMatch) {
+			ap([]string{toSnakeCase(fieldName)})
+		}
+		return envNames
+	}
+
+	// Add the field name and the field name in upper and lower case, unless names must match exactly
+	if _, exact := tagOptions[topt.EXACT]; exact || p.ExactNameMatch {
+		if len(envNames) == 0 {
+			ap([]string{fieldName})
+		}
+		return envNames
+	}
 	ap([]string{fieldName, strings.ToUpper(fieldName), strings.ToLower(fieldName)})
 
 	return envNames
 }
 
-// getEnvValue checks environment variables in order and returns the first non-empty value found.
-func getEnvValue(envNames []string) string {
+// getEnvValue checks environment variables in order and returns the name and value chosen by the resolve
+// policy among the set ones. Variables set to an empty value only count as set when the parser's EmptyIsSet
+// option is enabled. When a legacy prefix is configured, the legacy names are checked after all current
+// names are found unset. The names of the other set variables, which lost to the chosen one, are returned too.
+func (p *Parser) getEnvValue(envNames []string) (string, string, []string, error) {
+	var candidates []Candidate
+	for _, name := range envNames {
+		if val, ok := p.lookupSet(name); ok {
+			candidates = append(candidates, Candidate{Name: name, Value: val})
+		}
+	}
+	if len(candidates) > 0 {
+		chosen, err := p.resolveCandidates(candidates)
+		var ignored []string
+		for _, c := range candidates {
+			if c.Name != chosen.Name {
+				ignored = append(ignored, c.Name)
+			}
+		}
+		return chosen.Name, chosen.Value, ignored, err
+	}
+
+	if p.LegacyPrefix == "" {
+		return "", "", nil, nil
+	}
+	for _, name := range envNames {
+		legacy := p.LegacyPrefix + strings.TrimPrefix(name, p.NamePrefix)
+		if val, ok := p.lookupSet(legacy); ok {
+			p.warn("environment variable %s uses the legacy prefix %s, rename it to %s", legacy, p.LegacyPrefix, name)
+			return legacy, val, nil, nil
+		}
+	}
+	return "", "", nil, nil
+}
+
+// lookupEnv looks up the variable among the overrides, then through the configured lookup function, or else in the
+// process environment. Variables that are not set there are looked up in the loaded dotenv files.
+func (p *Parser) lookupEnv(name string) (string, bool) {
+	if val, ok := p.overrides[name]; ok {
+		return val, true
+	}
+	var val string
+	var ok bool
+	if p.Lookup != nil {
+		val, ok = p.Lookup(name)
+	} else {
+		val, ok = os.LookupEnv(name)
+	}
+	if !ok && p.dotenv != nil {
+		val, ok = p.dotenv[name]
+	}
+	return val, ok
+}
+
+// readFileVariant reads the file named by the first set <NAME>_FILE variable of the names, returning the variable
+// name and the file content without trailing newlines. The name is empty when no such variable is set.
+func (p *Parser) readFileVariant(names []string) (string, string, error) {
+	for _, name := range names {
+		path, ok := p.lookupSet(name + FileVariantSuffix)
+		if !ok || path == "" {
+			continue
+		}
+		content, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return name + FileVariantSuffix, "", fmt.Errorf("reading value from file: %w", err)
+		}
+		return name + FileVariantSuffix, strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return "", "", nil
+}
+
+// getenv returns the value of the variable, or an empty string if it is not set.
+func (p *Parser) getenv(name string) string {
+	val, _ := p.lookupEnv(name)
+	return val
+}
+
+// lookupSet returns the value of the environment variable and whether it counts as set.
+func (p *Parser) lookupSet(name string) (string, bool) {
+	val, ok := p.lookupEnv(name)
+	return val, ok && (val != "" || p.EmptyIsSet)
+}
+
+// splitQuoted splits the value by the separator, except inside double quotes or when the separator is escaped
+// with a backslash. Quotes are removed and escaped characters are kept literally (e.g., `"a|b"|c\|d` gives
+// "a|b" and "c|d").
+func splitQuoted(val, separator string) ([]string, error) {
+	var values []string
+	var cur strings.Builder
+	inQuotes := false
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; {
+		case c == '\\' && i+1 < len(val):
+			i++
+			cur.WriteByte(val[i])
+		case c == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(val[i:], separator):
+			values = append(values, cur.String())
+			cur.Reset()
+			i += len(separator) - 1
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in value: %s", val)
+	}
+	return append(values, cur.String()), nil
+}
+
+// toSnakeCase converts a Go field name to upper SNAKE_CASE, keeping acronyms together
+// (e.g., MaxRetryCount to MAX_RETRY_COUNT, HTTPServerPort to HTTP_SERVER_PORT).
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// trimValue applies the `trim`, `trimprefix` and `trimsuffix` options to the value.
+func trimValue(val string, tagOptions map[string]string) string {
+	if cutset, ok := tagOptions[topt.TRIM]; ok {
+		val = strings.Trim(val, cutset)
+	}
+	if prefix, ok := tagOptions[topt.TRIMPREFIX]; ok {
+		val = strings.TrimPrefix(val, prefix)
+	}
+	if suffix, ok := tagOptions[topt.TRIMSUFFIX]; ok {
+		val = strings.TrimSuffix(val, suffix)
+	}
+	return val
+}
+
+// setButEmpty returns the first of the names that is set to an empty or whitespace-only value, if any.
+func (p *Parser) setButEmpty(envNames []string) string {
 	for _, name := range envNames {
-		if val := os.Getenv(name); val != "" {
-			return val
+		if val, ok := p.lookupEnv(name); ok && strings.TrimSpace(stripBOMAndCR(val)) == "" {
+			return name
 		}
 	}
 	return ""
 }
 
+// stripBOMAndCR removes a leading UTF-8 byte-order mark and trailing carriage returns from the value.
+func stripBOMAndCR(val string) string {
+	return strings.TrimRight(strings.TrimPrefix(val, "\uFEFF"), "\r")
+}
+
+// warn reports a warning through the configured warning function, if any.
+func (p *Parser) warn(format string, args ...interface{}) {
+	if p.WarnFunc != nil {
+		p.WarnFunc(fmt.Sprintf(format, args...))
+	}
+}
+
 // setValue sets the value for a struct field based on its type.
-func setValue(field reflect.Value, val string, tagOptions map[string]string) error {
-	return setReflectValue(field, val, field.Kind(), tagOptions)
+func (p *Parser) setValue(field reflect.Value, val string, tagOptions map[string]string) error {
+	return p.setReflectValue(field, val, field.Kind(), tagOptions)
 }
 
 // setSliceValue sets the appropriate value for a slice element.
-func setSliceValue(sliceElement reflect.Value, val string, kind reflect.Kind, tagOptions map[string]string) error {
-	return setReflectValue(sliceElement, val, kind, tagOptions)
+func (p *Parser) setSliceValue(sliceElement reflect.Value, val string, kind reflect.Kind, tagOptions map[string]string) error {
+	return p.setReflectValue(sliceElement, val, kind, tagOptions)
+}
+
+// isLeafType reports whether a struct or slice type is decoded from a single value instead of being recursed into or split.
+func (p *Parser) isLeafType(t reflect.Type) bool {
+	return t == timeType || p.converter(t) != nil || reflect.PointerTo(t).Implements(textUnmarshalerType)
 }
 
 // setReflectValue sets the appropriate value based on the field's type.
-func setReflectValue(field reflect.Value, val string, kind reflect.Kind, tagOptions map[string]string) error {
+func (p *Parser) setReflectValue(field reflect.Value, val string, kind reflect.Kind, tagOptions map[string]string) error {
+	// Use a registered converter for the type, if any
+	if conv := p.converter(field.Type()); conv != nil {
+		return setConverted(field, val, conv)
+	}
+
+	// Map enum names to their numeric values
+	if enum, ok := tagOptions[topt.ENUM]; ok && kind != reflect.Ptr {
+		mapped, err := p.resolveEnum(val, enum, kind)
+		if err != nil {
+			return err
+		}
+		val = mapped
+	}
+
+	// Convert human-readable sizes to a number of bytes
+	if _, ok := tagOptions[topt.BYTESIZE]; ok && kind != reflect.Ptr && val != "" {
+		if !isIntegerKind(kind) {
+			return fmt.Errorf("bytesize option is only supported for integer types, got %s", kind)
+		}
+		size, err := ParseByteSize(val)
+		if err != nil {
+			return err
+		}
+		val = strconv.FormatInt(int64(size), 10)
+	}
+
+	// Convert percentages to fractions, or strip the percent sign in raw mode
+	if mode, ok := tagOptions[topt.PERCENT]; ok && kind != reflect.Ptr && val != "" {
+		converted, err := convertPercent(val, mode, kind)
+		if err != nil {
+			return err
+		}
+		val = converted
+	}
+
+	// Handle well-known types before falling back to their underlying kind
+	switch field.Type() {
+	case durationType:
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		if err := checkNonZero(d, tagOptions); err != nil {
+			return invalid(err)
+		}
+		field.SetInt(int64(d))
+		return nil
+	case timeType:
+		layout := tagOptions[topt.LAYOUT]
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	// Use the type's own text decoding, if implemented
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(val))
+		}
+	}
+
 	switch kind {
 	case reflect.String:
 		field.SetString(val)
@@ -235,8 +1425,8 @@ func setReflectValue(field reflect.Value, val string, kind reflect.Kind, tagOpti
 		if err != nil {
 			return err
 		}
-		if err := checkMinMax(intVal, tagOptions); err != nil {
-			return err
+		if err := checkBounds(intVal, tagOptions); err != nil {
+			return invalid(err)
 		}
 		field.SetInt(intVal)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -244,8 +1434,8 @@ func setReflectValue(field reflect.Value, val string, kind reflect.Kind, tagOpti
 		if err != nil {
 			return err
 		}
-		if err := checkMinMax(uintVal, tagOptions); err != nil {
-			return err
+		if err := checkBounds(uintVal, tagOptions); err != nil {
+			return invalid(err)
 		}
 		field.SetUint(uintVal)
 	case reflect.Float32, reflect.Float64:
@@ -253,25 +1443,142 @@ func setReflectValue(field reflect.Value, val string, kind reflect.Kind, tagOpti
 		if err != nil {
 			return err
 		}
-		if err := checkMinMax(floatVal, tagOptions); err != nil {
-			return err
+		if err := checkBounds(floatVal, tagOptions); err != nil {
+			return invalid(err)
 		}
 		field.SetFloat(floatVal)
 	case reflect.Bool:
-		boolVal, err := strconv.ParseBool(val)
+		boolVal, err := p.parseBool(val, tagOptions)
 		if err != nil {
 			return err
 		}
 		field.SetBool(boolVal)
+	case reflect.Ptr:
+		// Leave pointers nil when there is no value
+		if val == "" {
+			return nil
+		}
+		elem := reflect.New(field.Type().Elem())
+		if err := p.setReflectValue(elem.Elem(), val, elem.Elem().Kind(), tagOptions); err != nil {
+			return err
+		}
+		field.Set(elem)
 	default:
-		return errors.New("unsupported field type")
+		return fmt.Errorf("unsupported field type %s: use the json option, a registered converter, or a type implementing encoding.TextUnmarshaler", field.Type())
 	}
 	return nil
 }
 
+// parseBool parses a boolean, accepting the words of the `truthy` and `falsy` options (case-insensitive)
+// in addition to the values accepted by strconv.ParseBool.
+func (p *Parser) parseBool(val string, tagOptions map[string]string) (bool, error) {
+	matches := func(opt string) bool {
+		words, ok := tagOptions[opt]
+		if !ok {
+			return false
+		}
+		for _, w := range strings.Split(words, p.SliceValueSeparator) {
+			if strings.EqualFold(strings.TrimSpace(w), val) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case matches(topt.TRUTHY):
+		return true, nil
+	case matches(topt.FALSY):
+		return false, nil
+	}
+	return strconv.ParseBool(val)
+}
+
+// isIntegerKind reports whether the kind is a signed or unsigned integer.
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// convertPercent converts a percentage (e.g., "75%") to a fraction ("0.75") for float fields.
+// With the "raw" mode, only the percent sign is removed ("75"), which also works for integer fields.
+func convertPercent(val string, mode string, kind reflect.Kind) (string, error) {
+	num := strings.TrimSpace(strings.TrimSuffix(val, "%"))
+	switch mode {
+	case "raw":
+		return num, nil
+	case "":
+		if kind != reflect.Float32 && kind != reflect.Float64 {
+			return "", fmt.Errorf("percent option is only supported for float types, got %s (use percent=raw)", kind)
+		}
+		// Move the decimal point rather than dividing, so 33.3% gives 0.333 rather than 0.33299999999999996
+		if f, err := strconv.ParseFloat(num+"e-2", 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid percentage: %s", val)
+		}
+		return strconv.FormatFloat(f/100, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("invalid percent mode: %s", mode)
+	}
+}
+
+// resolveEnum maps a value to its number using the enum option (e.g., "debug:0|info:1|warn:2").
+func (p *Parser) resolveEnum(val string, enum string, kind reflect.Kind) (string, error) {
+	if !isIntegerKind(kind) {
+		return "", fmt.Errorf("enum option is only supported for integer types, got %s", kind)
+	}
+
+	var allowed []string
+	for _, entry := range strings.Split(enum, p.SliceValueSeparator) {
+		name, num, ok := strings.Cut(entry, ":")
+		if !ok {
+			return "", fmt.Errorf("invalid enum entry: %s. Expected format: name:number", entry)
+		}
+		name = strings.TrimSpace(name)
+		if name == val {
+			return strings.TrimSpace(num), nil
+		}
+		allowed = append(allowed, name)
+	}
+	return "", fmt.Errorf("invalid value %s: allowed values are %s", val, strings.Join(allowed, ", "))
+}
+
+// isSupportedElem reports whether slice elements of the type can be decoded from a single value.
+func (p *Parser) isSupportedElem(t reflect.Type) bool {
+	if p.isLeafType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return p.isSupportedElem(t.Elem())
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer, reflect.Uintptr:
+		return false
+	}
+	return true
+}
+
+// sliceSeparator returns the separator of a slice field's values: the `separator` option, or the configured
+// slice value separator.
+func (p *Parser) sliceSeparator(tagOptions map[string]string) string {
+	if sep := tagOptions[topt.SEPARATOR]; sep != "" {
+		return sep
+	}
+	return p.SliceValueSeparator
+}
+
 // handleSliceWithSeparator processes slice types, splitting the input string using a specified separator.
-func handleSliceWithSeparator(field reflect.Value, envVal string, tagOptions map[string]string, separator string) error {
+func (p *Parser) handleSliceWithSeparator(field reflect.Value, envVal string, tagOptions map[string]string, separator string) error {
 	sliceType := field.Type().Elem().Kind()
+	if !p.isSupportedElem(field.Type().Elem()) {
+		return fmt.Errorf("unsupported slice element type %s: use the json option, a registered converter, or a type implementing encoding.TextUnmarshaler", field.Type().Elem())
+	}
 
 	if envVal == "" {
 		field.Set(reflect.MakeSlice(field.Type(), 0, 0))
@@ -279,15 +1586,21 @@ func handleSliceWithSeparator(field reflect.Value, envVal string, tagOptions map
 	}
 	_, notrim := tagOptions[topt.NOTRIM]
 
-	// Split the environment variable by the separator
+	// Split the environment variable by the separator, honoring quotes and escapes if requested
 	values := strings.Split(envVal, separator)
+	if _, quoted := tagOptions[topt.QUOTED]; quoted {
+		var err error
+		if values, err = splitQuoted(envVal, separator); err != nil {
+			return err
+		}
+	}
 	// Filter out any empty elements (after trimming)
 	filteredValues := []string{}
 	for _, val := range values {
 		if notrim {
-			filteredValues = append(filteredValues, val)
+			filteredValues = append(filteredValues, trimValue(val, tagOptions))
 		} else {
-			trimmedVal := strings.TrimSpace(val)
+			trimmedVal := trimValue(strings.TrimSpace(val), tagOptions)
 			if trimmedVal != "" {
 				filteredValues = append(filteredValues, trimmedVal)
 			}
@@ -304,53 +1617,109 @@ func handleSliceWithSeparator(field reflect.Value, envVal string, tagOptions map
 	newSlice := reflect.MakeSlice(field.Type(), len(filteredValues), len(filteredValues))
 
 	for i, val := range filteredValues {
-		err := setSliceValue(newSlice.Index(i), val, sliceType, tagOptions)
+		if err := p.checkForValidation(val, tagOptions); err != nil {
+			return invalid(err)
+		}
+		err := p.setSliceValue(newSlice.Index(i), val, sliceType, tagOptions)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Deduplicate and sort the elements, if requested
+	newSlice, err := normalizeSlice(newSlice, tagOptions)
+	if err != nil {
+		return invalid(err)
+	}
+
 	field.Set(newSlice)
 	return nil
 }
 
-// checkMinMax validates if the value is within the range specified by the "min" and "max" tags.
-func checkMinMax(val interface{}, tagOptions map[string]string) error {
-	if minStr, ok := tagOptions[topt.MIN]; ok {
-		min, err := strconv.ParseFloat(minStr, 64)
-		if err != nil {
-			return fmt.Errorf("invalid min value: %s", minStr)
+// numericBounds lists the bound options, the comparison result they accept, and their error message.
+var numericBounds = []struct {
+	opt string
+	ok  func(cmp int) bool
+	msg string
+}{
+	{topt.MIN, func(cmp int) bool { return cmp >= 0 }, "value %v is less than minimum allowed %s"},
+	{topt.MAX, func(cmp int) bool { return cmp <= 0 }, "value %v is greater than maximum allowed %s"},
+	{topt.GT, func(cmp int) bool { return cmp > 0 }, "value %v must be greater than %s"},
+	{topt.GTE, func(cmp int) bool { return cmp >= 0 }, "value %v must be greater than or equal to %s"},
+	{topt.LT, func(cmp int) bool { return cmp < 0 }, "value %v must be less than %s"},
+	{topt.LTE, func(cmp int) bool { return cmp <= 0 }, "value %v must be less than or equal to %s"},
+}
+
+// checkBounds validates the value against the "min", "max", "gt", "gte", "lt" and "lte" tags.
+// Integers are compared exactly, so large int64 and uint64 values keep their precision.
+func checkBounds(val interface{}, tagOptions map[string]string) error {
+	for _, b := range numericBounds {
+		boundStr, ok := tagOptions[b.opt]
+		if !ok {
+			continue
+		}
+		bound, ok := new(big.Rat).SetString(strings.TrimSpace(boundStr))
+		if !ok {
+			return fmt.Errorf("invalid %s value: %s", b.opt, boundStr)
 		}
-		if compareNumeric(val, min) < 0 {
-			return fmt.Errorf("value %v is less than minimum allowed %v", val, min)
+		if !b.ok(compareBound(val, bound)) {
+			return fmt.Errorf(b.msg, val, boundStr)
 		}
 	}
+	if err := checkNonZero(val, tagOptions); err != nil {
+		return err
+	}
+	return checkMultipleOf(val, tagOptions)
+}
 
-	if maxStr, ok := tagOptions[topt.MAX]; ok {
-		max, err := strconv.ParseFloat(maxStr, 64)
-		if err != nil {
-			return fmt.Errorf("invalid max value: %s", maxStr)
-		}
-		if compareNumeric(val, max) > 0 {
-			return fmt.Errorf("value %v is greater than maximum allowed %v", val, max)
-		}
+// checkNonZero validates that the value is not zero when the "nonzero" tag is set.
+func checkNonZero(val interface{}, tagOptions map[string]string) error {
+	if _, ok := tagOptions[topt.NONZERO]; ok && reflect.ValueOf(val).IsZero() {
+		return fmt.Errorf("value %v must not be zero", val)
 	}
 	return nil
 }
 
-// compareNumeric compares two numeric values and returns -1 if val < threshold, 0 if equal, and 1 if val > threshold.
-func compareNumeric(val interface{}, threshold float64) int {
+// checkMultipleOf validates that an integer value is an exact multiple of the "multipleof" tag.
+func checkMultipleOf(val interface{}, tagOptions map[string]string) error {
+	divStr, ok := tagOptions[topt.MULTIPLEOF]
+	if !ok {
+		return nil
+	}
+	div, ok := new(big.Int).SetString(strings.TrimSpace(divStr), 10)
+	if !ok || div.Sign() == 0 {
+		return fmt.Errorf("invalid multipleof value: %s", divStr)
+	}
+
+	var v *big.Int
+	switch n := val.(type) {
+	case int64:
+		v = big.NewInt(n)
+	case uint64:
+		v = new(big.Int).SetUint64(n)
+	default:
+		return fmt.Errorf("multipleof only applies to integer fields")
+	}
+	if new(big.Int).Rem(v, div).Sign() != 0 {
+		return fmt.Errorf("value %v is not a multiple of %s", val, divStr)
+	}
+	return nil
+}
+
+// compareBound compares a numeric value with a bound and returns -1 if val < bound, 0 if equal, and 1 if val > bound.
+// Floats are compared as floats, so that a bound like 3.14 equals the parsed value 3.14.
+func compareBound(val interface{}, bound *big.Rat) int {
 	switch v := val.(type) {
 	case int64:
-		if float64(v) < threshold {
-			return -1
-		} else if float64(v) > threshold {
-			return 1
-		}
+		return new(big.Rat).SetInt64(v).Cmp(bound)
+	case uint64:
+		return new(big.Rat).SetUint64(v).Cmp(bound)
 	case float64:
-		if v < threshold {
+		f, _ := bound.Float64()
+		switch {
+		case v < f:
 			return -1
-		} else if v > threshold {
+		case v > f:
 			return 1
 		}
 	}
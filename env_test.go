@@ -1,8 +1,22 @@
 package env_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/igwtcode/go-env"
 )
@@ -2536,3 +2550,4076 @@ func TestOptionalFieldWithAwsValidator(t *testing.T) {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
+
+func TestValidAwsSqsQueueURL(t *testing.T) {
+	type Config struct {
+		QueueURL string `env:"name=AWS_SQS_QUEUE_URL,v_aws_sqs_queue_url"`
+	}
+	defer os.Unsetenv("AWS_SQS_QUEUE_URL")
+
+	for _, url := range []string{
+		"https://sqs.us-east-1.amazonaws.com/123456789012/my-queue",
+		"https://sqs.eu-west-1.amazonaws.com/123456789012/orders_v2.fifo",
+		"https://us-west-2.queue.amazonaws.com/123456789012/legacy",
+		"https://sqs.cn-north-1.amazonaws.com.cn/123456789012/" + strings.Repeat("q", 75) + ".fifo",
+	} {
+		os.Setenv("AWS_SQS_QUEUE_URL", url)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err != nil {
+			t.Errorf("expected no error for %s, got %v", url, err)
+		}
+	}
+}
+
+func TestInvalidAwsSqsQueueURL(t *testing.T) {
+	type Config struct {
+		QueueURL string `env:"name=AWS_SQS_QUEUE_URL,v_aws_sqs_queue_url"`
+	}
+	defer os.Unsetenv("AWS_SQS_QUEUE_URL")
+
+	for _, url := range []string{
+		"my-queue",
+		"http://sqs.us-east-1.amazonaws.com/123456789012/my-queue",
+		"https://sqs.us-east-1.amazonaws.com/1234/my-queue",
+		"https://sqs.useast1.amazonaws.com/123456789012/my-queue",
+		"https://sqs.us-east-1.amazonaws.com/123456789012/my.queue",
+		"https://sqs.us-east-1.amazonaws.com/123456789012/my-queue.fifo.fifo",
+		"https://sqs.us-east-1.amazonaws.com/123456789012/" + strings.Repeat("q", 76) + ".fifo",
+	} {
+		os.Setenv("AWS_SQS_QUEUE_URL", url)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err == nil {
+			t.Errorf("expected an error for invalid AWS SQS queue URL %s, got none", url)
+		}
+	}
+}
+
+func TestValidAwsSnsTopicArn(t *testing.T) {
+	type Config struct {
+		TopicArn string `env:"name=AWS_SNS_TOPIC_ARN,v_aws_sns_topic_arn"`
+	}
+	defer os.Unsetenv("AWS_SNS_TOPIC_ARN")
+
+	for _, arn := range []string{
+		"arn:aws:sns:us-east-1:123456789012:alerts",
+		"arn:aws:sns:eu-central-1:123456789012:orders_v2.fifo",
+		"arn:aws-us-gov:sns:us-gov-west-1:123456789012:alerts",
+	} {
+		os.Setenv("AWS_SNS_TOPIC_ARN", arn)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err != nil {
+			t.Errorf("expected no error for %s, got %v", arn, err)
+		}
+	}
+}
+
+func TestInvalidAwsSnsTopicArn(t *testing.T) {
+	type Config struct {
+		TopicArn string `env:"name=AWS_SNS_TOPIC_ARN,v_aws_sns_topic_arn"`
+	}
+	defer os.Unsetenv("AWS_SNS_TOPIC_ARN")
+
+	for arn, want := range map[string]string{
+		"arn:aws:sqs:us-east-1:123456789012:alerts":                                      "Must be an SNS ARN, not sqs",
+		"arn:aws:sns:us-east-1:123456789012:alerts:3f1c2d4e-5a6b-4c7d-8e9f-0a1b2c3d4e5f": "Must be in the format",
+		"arn:aws:sns:us-east-1:1234:alerts":                                              "Must be in the format",
+		"arn:aws:sns:useast1:123456789012:alerts":                                        "Invalid region name",
+		"arn:aws:sns:us-east-1:123456789012:" + strings.Repeat("t", 252) + ".fifo":       "at most 256 characters",
+		"alerts": "Must be in the format",
+	} {
+		os.Setenv("AWS_SNS_TOPIC_ARN", arn)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected an error containing %q for %s, got %v", want, arn, err)
+		}
+	}
+}
+
+func TestValidAwsLambda(t *testing.T) {
+	type Config struct {
+		Function string `env:"name=AWS_LAMBDA_FUNCTION,v_aws_lambda"`
+	}
+	defer os.Unsetenv("AWS_LAMBDA_FUNCTION")
+
+	for _, fn := range []string{
+		"my-function",
+		"my_function:prod",
+		"123456789012:function:my-function",
+		"123456789012:function:my-function:7",
+		"arn:aws:lambda:us-east-1:123456789012:function:my-function",
+		"arn:aws:lambda:eu-west-1:123456789012:function:my-function:$LATEST",
+		"arn:aws-cn:lambda:cn-north-1:123456789012:function:my-function:live",
+	} {
+		os.Setenv("AWS_LAMBDA_FUNCTION", fn)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err != nil {
+			t.Errorf("expected no error for %s, got %v", fn, err)
+		}
+	}
+}
+
+func TestInvalidAwsLambda(t *testing.T) {
+	type Config struct {
+		Function string `env:"name=AWS_LAMBDA_FUNCTION,v_aws_lambda"`
+	}
+	defer os.Unsetenv("AWS_LAMBDA_FUNCTION")
+
+	for _, fn := range []string{
+		"my.function",
+		strings.Repeat("f", 65),
+		"12345:function:my-function",
+		"arn:aws:lambda:us-east-1:123456789012:layer:my-layer",
+		"arn:aws:s3:::my-bucket",
+		"arn:aws:lambda:useast1:123456789012:function:my-function",
+		"my-function:01",
+		"my-function:prod:1",
+	} {
+		os.Setenv("AWS_LAMBDA_FUNCTION", fn)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err == nil {
+			t.Errorf("expected an error for invalid AWS Lambda function %s, got none", fn)
+		}
+	}
+}
+
+func TestValidAwsKmsKey(t *testing.T) {
+	type Config struct {
+		KeyID string `env:"name=AWS_KMS_KEY,v_aws_kms_key"`
+	}
+	defer os.Unsetenv("AWS_KMS_KEY")
+
+	for _, key := range []string{
+		"1234abcd-12ab-34cd-56ef-1234567890ab",
+		"mrk-1234abcd12ab34cd56ef1234567890ab",
+		"alias/my-key",
+		"alias/aws/s3",
+		"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws:kms:eu-west-1:123456789012:alias/app/prod_key",
+	} {
+		os.Setenv("AWS_KMS_KEY", key)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err != nil {
+			t.Errorf("expected no error for %s, got %v", key, err)
+		}
+	}
+}
+
+func TestInvalidAwsKmsKey(t *testing.T) {
+	type Config struct {
+		KeyID string `env:"name=AWS_KMS_KEY,v_aws_kms_key"`
+	}
+	defer os.Unsetenv("AWS_KMS_KEY")
+
+	for _, key := range []string{
+		"my-key",
+		"1234ABCD-12AB-34CD-56EF-1234567890AB",
+		"key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"alias/",
+		"alias/my key",
+		"arn:aws:kms:us-east-1:123456789012:1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws:kms:us-east-1:123456789012:key/my-key",
+		"arn:aws:kms:useast1:123456789012:alias/my-key",
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:my-secret",
+	} {
+		os.Setenv("AWS_KMS_KEY", key)
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err == nil {
+			t.Errorf("expected an error for invalid AWS KMS key %s, got none", key)
+		}
+	}
+}
+
+func TestExecValue(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,required"`
+	}
+
+	os.Setenv("DB_PASSWORD", "exec:/bin/echo s3cret")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	parser := env.NewParser().WithExecAllowlist("/bin/echo")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Password != "s3cret" {
+		t.Errorf("expected Password to be 's3cret', got %v", cfg.Password)
+	}
+}
+
+func TestExecValueInDefault(t *testing.T) {
+	type Config struct {
+		Token string `env:"name=API_TOKEN,default=exec:/bin/echo from-default"`
+	}
+
+	os.Unsetenv("API_TOKEN")
+
+	parser := env.NewParser().WithExecAllowlist("/bin/echo")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Token != "from-default" {
+		t.Errorf("expected Token to be 'from-default', got %v", cfg.Token)
+	}
+}
+
+func TestExecValueDisabledByDefault(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+	}
+
+	os.Setenv("DB_PASSWORD", "exec:/bin/echo s3cret")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Password != "exec:/bin/echo s3cret" {
+		t.Errorf("expected Password to be kept as is, got %v", cfg.Password)
+	}
+}
+
+func TestExecValueNotAllowed(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+	}
+
+	os.Setenv("DB_PASSWORD", "exec:/bin/cat /etc/passwd")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	parser := env.NewParser().WithExecAllowlist("/bin/echo")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for a command not in the allowlist, got none")
+	}
+}
+
+func TestExecValueTimeout(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+	}
+
+	os.Setenv("DB_PASSWORD", "exec:/bin/sleep 2")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	parser := env.NewParser().WithExecAllowlist("/bin/sleep").WithExecTimeout(50 * time.Millisecond)
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected a timeout error, got none")
+	}
+}
+
+func TestDurationValue(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `env:"name=TIMEOUT,default=30s"`
+	}
+
+	os.Setenv("TIMEOUT", "1m30s")
+	defer os.Unsetenv("TIMEOUT")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Timeout != 90*time.Second {
+		t.Errorf("expected Timeout to be 1m30s, got %v", cfg.Timeout)
+	}
+}
+
+func TestSliceOfDurations(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration `env:"name=RETRY_BACKOFFS"`
+	}
+
+	os.Setenv("RETRY_BACKOFFS", "1s|5s|30s")
+	defer os.Unsetenv("RETRY_BACKOFFS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+	if len(cfg.Backoffs) != len(expected) {
+		t.Fatalf("expected %d backoffs, got %d", len(expected), len(cfg.Backoffs))
+	}
+	for i, d := range expected {
+		if cfg.Backoffs[i] != d {
+			t.Errorf("expected Backoffs[%d] to be %v, got %v", i, d, cfg.Backoffs[i])
+		}
+	}
+}
+
+func TestInvalidDurationInSlice(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration `env:"name=RETRY_BACKOFFS"`
+	}
+
+	os.Setenv("RETRY_BACKOFFS", "1s|five|30s")
+	defer os.Unsetenv("RETRY_BACKOFFS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for invalid duration, got none")
+	}
+}
+
+func TestTimeValueWithDefaultLayout(t *testing.T) {
+	type Config struct {
+		Since time.Time `env:"name=SINCE"`
+	}
+
+	os.Setenv("SINCE", "2024-05-01T10:00:00Z")
+	defer os.Unsetenv("SINCE")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if !cfg.Since.Equal(expected) {
+		t.Errorf("expected Since to be %v, got %v", expected, cfg.Since)
+	}
+}
+
+func TestSliceOfTimesWithLayout(t *testing.T) {
+	type Config struct {
+		Holidays []time.Time `env:"name=HOLIDAYS,layout=2006-01-02"`
+	}
+
+	os.Setenv("HOLIDAYS", "2024-12-25|2025-01-01")
+	defer os.Unsetenv("HOLIDAYS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []time.Time{
+		time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if len(cfg.Holidays) != len(expected) {
+		t.Fatalf("expected %d holidays, got %d", len(expected), len(cfg.Holidays))
+	}
+	for i, d := range expected {
+		if !cfg.Holidays[i].Equal(d) {
+			t.Errorf("expected Holidays[%d] to be %v, got %v", i, d, cfg.Holidays[i])
+		}
+	}
+}
+
+func TestTimeWindowValue(t *testing.T) {
+	type Config struct {
+		QuietHours env.TimeWindow   `env:"name=QUIET_HOURS,default=22:00-06:00"`
+		Windows    []env.TimeWindow `env:"name=MAINTENANCE_WINDOWS"`
+	}
+
+	os.Setenv("MAINTENANCE_WINDOWS", "01:00-02:00 Europe/Berlin|13:00-13:30")
+	defer os.Unsetenv("MAINTENANCE_WINDOWS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.QuietHours.String() != "22:00-06:00" {
+		t.Errorf("expected QuietHours to be '22:00-06:00', got %v", cfg.QuietHours)
+	}
+	if len(cfg.Windows) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(cfg.Windows))
+	}
+	if cfg.Windows[0].String() != "01:00-02:00 Europe/Berlin" {
+		t.Errorf("expected Windows[0] to be '01:00-02:00 Europe/Berlin', got %v", cfg.Windows[0])
+	}
+}
+
+func TestCustomConverter(t *testing.T) {
+	type Level int
+	type Config struct {
+		Level  Level   `env:"name=LEVEL"`
+		Levels []Level `env:"name=LEVELS"`
+	}
+
+	os.Setenv("LEVEL", "high")
+	os.Setenv("LEVELS", "low|high")
+	defer os.Unsetenv("LEVEL")
+	defer os.Unsetenv("LEVELS")
+
+	parser := env.NewParser().WithConverter(Level(0), func(val string) (interface{}, error) {
+		switch val {
+		case "low":
+			return Level(1), nil
+		case "high":
+			return Level(2), nil
+		}
+		return nil, fmt.Errorf("unknown level %q", val)
+	})
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Level != 2 {
+		t.Errorf("expected Level to be 2, got %v", cfg.Level)
+	}
+	if len(cfg.Levels) != 2 || cfg.Levels[0] != 1 || cfg.Levels[1] != 2 {
+		t.Errorf("expected Levels to be [1 2], got %v", cfg.Levels)
+	}
+}
+
+func TestEnumValue(t *testing.T) {
+	type LogLevel int
+	type Config struct {
+		Level  LogLevel   `env:"name=LOG_LEVEL,lower,enum=debug:0|info:1|warn:2,default=info"`
+		Levels []LogLevel `env:"name=LOG_LEVELS,enum=debug:0|info:1|warn:2"`
+	}
+
+	os.Setenv("LOG_LEVEL", "WARN")
+	os.Setenv("LOG_LEVELS", "debug|warn")
+	defer os.Unsetenv("LOG_LEVEL")
+	defer os.Unsetenv("LOG_LEVELS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Level != 2 {
+		t.Errorf("expected Level to be 2, got %v", cfg.Level)
+	}
+	if len(cfg.Levels) != 2 || cfg.Levels[0] != 0 || cfg.Levels[1] != 2 {
+		t.Errorf("expected Levels to be [0 2], got %v", cfg.Levels)
+	}
+}
+
+func TestEnumDefaultValue(t *testing.T) {
+	type Config struct {
+		Level uint8 `env:"name=LOG_LEVEL,enum=debug:0|info:1|warn:2,default=info"`
+	}
+
+	os.Unsetenv("LOG_LEVEL")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Level != 1 {
+		t.Errorf("expected Level to be 1, got %v", cfg.Level)
+	}
+}
+
+func TestInvalidEnumValue(t *testing.T) {
+	type Config struct {
+		Level int `env:"name=LOG_LEVEL,enum=debug:0|info:1|warn:2"`
+	}
+
+	os.Setenv("LOG_LEVEL", "trace")
+	defer os.Unsetenv("LOG_LEVEL")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for invalid enum value, got none")
+	}
+	if !strings.Contains(err.Error(), "debug, info, warn") {
+		t.Errorf("expected error to list allowed values, got %v", err)
+	}
+}
+
+func TestEnumOnStringField(t *testing.T) {
+	type Config struct {
+		Level string `env:"name=LOG_LEVEL,enum=debug:0|info:1"`
+	}
+
+	os.Setenv("LOG_LEVEL", "debug")
+	defer os.Unsetenv("LOG_LEVEL")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for enum on a string field, got none")
+	}
+}
+
+func TestByteSizeValue(t *testing.T) {
+	type Config struct {
+		CacheSize env.ByteSize   `env:"name=CACHE_SIZE,default=64Mi"`
+		Limits    []env.ByteSize `env:"name=LIMITS"`
+	}
+
+	os.Setenv("LIMITS", "512Mi|1.5GB")
+	defer os.Unsetenv("LIMITS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.CacheSize != 64*env.MiB {
+		t.Errorf("expected CacheSize to be 64Mi, got %v", cfg.CacheSize)
+	}
+	if len(cfg.Limits) != 2 || cfg.Limits[0].String() != "512Mi" || cfg.Limits[1].String() != "1.5GB" {
+		t.Errorf("expected Limits to be [512Mi 1.5GB], got %v", cfg.Limits)
+	}
+}
+
+func TestSliceOfPointers(t *testing.T) {
+	type Config struct {
+		Ports     []*int     `env:"name=PORTS"`
+		Names     []*string  `env:"name=NAMES"`
+		Endpoints []*url.URL `env:"name=ENDPOINTS"`
+	}
+
+	os.Setenv("PORTS", "80|443")
+	os.Setenv("NAMES", "a|b")
+	os.Setenv("ENDPOINTS", "https://example.com/api|http://localhost:8080")
+	defer os.Unsetenv("PORTS")
+	defer os.Unsetenv("NAMES")
+	defer os.Unsetenv("ENDPOINTS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(cfg.Ports) != 2 || *cfg.Ports[0] != 80 || *cfg.Ports[1] != 443 {
+		t.Errorf("expected Ports to be [80 443], got %v", cfg.Ports)
+	}
+	if len(cfg.Names) != 2 || *cfg.Names[0] != "a" || *cfg.Names[1] != "b" {
+		t.Errorf("expected Names to be [a b], got %v", cfg.Names)
+	}
+	if len(cfg.Endpoints) != 2 || cfg.Endpoints[0].Host != "example.com" || cfg.Endpoints[1].Port() != "8080" {
+		t.Errorf("expected Endpoints to be parsed, got %v", cfg.Endpoints)
+	}
+}
+
+func TestPointerFieldUnset(t *testing.T) {
+	type Config struct {
+		Port *int     `env:"name=PORT"`
+		URL  *url.URL `env:"name=URL,default=https://example.com"`
+	}
+
+	os.Unsetenv("PORT")
+	os.Unsetenv("URL")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Port != nil {
+		t.Errorf("expected Port to be nil, got %v", *cfg.Port)
+	}
+	if cfg.URL == nil || cfg.URL.Host != "example.com" {
+		t.Errorf("expected URL to be 'https://example.com', got %v", cfg.URL)
+	}
+}
+
+func TestSliceOfTextUnmarshalers(t *testing.T) {
+	type Config struct {
+		IPs []net.IP `env:"name=IPS"`
+	}
+
+	os.Setenv("IPS", "10.0.0.1|::1")
+	defer os.Unsetenv("IPS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(cfg.IPs) != 2 || cfg.IPs[0].String() != "10.0.0.1" || cfg.IPs[1].String() != "::1" {
+		t.Errorf("expected IPs to be [10.0.0.1 ::1], got %v", cfg.IPs)
+	}
+}
+
+func TestInvalidPointerSliceElement(t *testing.T) {
+	type Config struct {
+		Ports []*int `env:"name=PORTS"`
+	}
+
+	os.Setenv("PORTS", "80|http")
+	defer os.Unsetenv("PORTS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for invalid element, got none")
+	}
+}
+
+func TestTextUnmarshalerSliceType(t *testing.T) {
+	type Config struct {
+		IP net.IP `env:"name=BIND_IP,default=127.0.0.1"`
+	}
+
+	os.Unsetenv("BIND_IP")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.IP.String() != "127.0.0.1" {
+		t.Errorf("expected IP to be '127.0.0.1', got %v", cfg.IP)
+	}
+}
+
+type EmbeddedDatabase struct {
+	DbHost string `env:"name=HOST,default=localhost"`
+	DbPort int    `env:"name=PORT,default=5432"`
+}
+
+type EmbeddedServer struct {
+	Host string `env:"name=HOST"`
+}
+
+func TestEmbeddedStructWithPrefix(t *testing.T) {
+	type Config struct {
+		EmbeddedServer
+		EmbeddedDatabase `env:"prefix=DB_"`
+	}
+
+	os.Setenv("APP_HOST", "web.local")
+	os.Setenv("APP_DB_HOST", "db.local")
+	os.Setenv("APP_DB_PORT", "6543")
+	defer os.Unsetenv("APP_HOST")
+	defer os.Unsetenv("APP_DB_HOST")
+	defer os.Unsetenv("APP_DB_PORT")
+
+	parser := env.NewParser().WithNamePrefix("APP_")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Host != "web.local" {
+		t.Errorf("expected Host to be 'web.local', got %v", cfg.Host)
+	}
+	if cfg.DbHost != "db.local" {
+		t.Errorf("expected DbHost to be 'db.local', got %v", cfg.DbHost)
+	}
+	if cfg.DbPort != 6543 {
+		t.Errorf("expected DbPort to be 6543, got %v", cfg.DbPort)
+	}
+}
+
+type EmbeddedBase struct {
+	BaseName string `env:"name=BASE_NAME"`
+}
+
+func TestEmbeddedStructWithoutPrefix(t *testing.T) {
+	type Config struct {
+		EmbeddedBase
+	}
+
+	os.Setenv("BASE_NAME", "promoted")
+	defer os.Unsetenv("BASE_NAME")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.BaseName != "promoted" {
+		t.Errorf("expected BaseName to be 'promoted', got %v", cfg.BaseName)
+	}
+}
+
+func TestRewritePrefix(t *testing.T) {
+	environ := []string{"OLD_PORT=8080", "OLD_HOST=a", "NEW_HOST=b", "PATH=/bin", "OLD_EMPTY="}
+
+	got := env.RewritePrefix(environ, "OLD_", "NEW_")
+
+	expected := []string{"NEW_PORT=8080", "NEW_HOST=b", "PATH=/bin", "NEW_EMPTY="}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected entry %d to be %v, got %v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestLegacyPrefix(t *testing.T) {
+	type Config struct {
+		Port int    `env:"name=PORT"`
+		Host string `env:"name=HOST"`
+	}
+
+	os.Setenv("OLDAPP_PORT", "8080")
+	os.Setenv("OLDAPP_HOST", "old.local")
+	os.Setenv("NEWAPP_HOST", "new.local")
+	defer os.Unsetenv("OLDAPP_PORT")
+	defer os.Unsetenv("OLDAPP_HOST")
+	defer os.Unsetenv("NEWAPP_HOST")
+
+	var warnings []string
+	parser := env.NewParser().
+		WithNamePrefix("NEWAPP_").
+		WithLegacyPrefix("OLDAPP_").
+		WithWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Port != 8080 {
+		t.Errorf("expected Port to be 8080, got %v", cfg.Port)
+	}
+	if cfg.Host != "new.local" {
+		t.Errorf("expected Host to be 'new.local', got %v", cfg.Host)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "OLDAPP_PORT") {
+		t.Errorf("expected one warning about OLDAPP_PORT, got %v", warnings)
+	}
+}
+
+func TestResolvePolicyFirstWinsByDefault(t *testing.T) {
+	type Config struct {
+		Region string `env:"name=AWS_DEFAULT_REGION|AWS_REGION"`
+	}
+
+	os.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	os.Setenv("AWS_REGION", "us-east-1")
+	defer os.Unsetenv("AWS_DEFAULT_REGION")
+	defer os.Unsetenv("AWS_REGION")
+
+	var warnings []string
+	parser := env.NewParser().WithWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("expected Region to be 'eu-west-1', got %v", cfg.Region)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected one conflict warning, got %v", warnings)
+	}
+}
+
+func TestResolvePolicyErrorOnConflict(t *testing.T) {
+	type Config struct {
+		Region string `env:"name=AWS_DEFAULT_REGION|AWS_REGION"`
+	}
+
+	os.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	os.Setenv("AWS_REGION", "us-east-1")
+	defer os.Unsetenv("AWS_DEFAULT_REGION")
+	defer os.Unsetenv("AWS_REGION")
+
+	parser := env.NewParser().WithResolvePolicy(env.ErrorOnConflict)
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for conflicting values, got none")
+	}
+
+	os.Setenv("AWS_REGION", "eu-west-1")
+	err = parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error for equal values, got %v", err)
+	}
+}
+
+func TestResolvePolicyLastWins(t *testing.T) {
+	type Config struct {
+		Port int `env:"name=PORT|APP_PORT|OVERRIDE_PORT,exact"`
+	}
+
+	os.Setenv("PORT", "8080")
+	os.Setenv("APP_PORT", "9090")
+	os.Unsetenv("OVERRIDE_PORT")
+	defer os.Unsetenv("PORT")
+	defer os.Unsetenv("APP_PORT")
+
+	var warnings []string
+	parser := env.NewParser().WithResolvePolicy(env.LastWins).WithWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Port != 9090 {
+		t.Errorf("expected Port to be 9090, got %v", cfg.Port)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "using APP_PORT") {
+		t.Errorf("expected a conflict warning naming APP_PORT, got %v", warnings)
+	}
+
+	res, err := parser.Resolve(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(res) != 1 || res[0].Var != "APP_PORT" || res[0].Ignored != "PORT" {
+		t.Errorf("expected PORT to be reported as ignored, got %+v", res)
+	}
+	var buf bytes.Buffer
+	if err := parser.PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "APP_PORT (ignored PORT)") {
+		t.Errorf("expected the table to show the ignored variable, got:\n%s", buf.String())
+	}
+}
+
+func TestResolvePolicyPreferLongestPrefix(t *testing.T) {
+	type Config struct {
+		Region string `env:"name=AWS_REGION|AWS_DEFAULT_REGION"`
+	}
+
+	os.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	os.Setenv("AWS_REGION", "us-east-1")
+	defer os.Unsetenv("AWS_DEFAULT_REGION")
+	defer os.Unsetenv("AWS_REGION")
+
+	parser := env.NewParser().WithResolvePolicy(env.PreferLongestPrefix)
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("expected Region to be 'eu-west-1', got %v", cfg.Region)
+	}
+}
+
+func TestCustomResolvePolicy(t *testing.T) {
+	type Config struct {
+		Region string `env:"name=AWS_DEFAULT_REGION|AWS_REGION"`
+	}
+
+	os.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	os.Setenv("AWS_REGION", "us-east-1")
+	defer os.Unsetenv("AWS_DEFAULT_REGION")
+	defer os.Unsetenv("AWS_REGION")
+
+	parser := env.NewParser().WithResolvePolicy(func(candidates []env.Candidate) (env.Candidate, error) {
+		return candidates[len(candidates)-1], nil
+	})
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Region != "us-east-1" {
+		t.Errorf("expected Region to be 'us-east-1', got %v", cfg.Region)
+	}
+}
+
+func TestByteSizeOption(t *testing.T) {
+	type Config struct {
+		MaxBody  int64    `env:"name=MAX_BODY,bytesize"`
+		Buffer   uint32   `env:"name=BUFFER,bytesize,default=512KiB"`
+		Segments []uint64 `env:"name=SEGMENTS,bytesize"`
+	}
+
+	os.Setenv("MAX_BODY", "10MB")
+	os.Setenv("SEGMENTS", "1GiB|512k")
+	defer os.Unsetenv("MAX_BODY")
+	defer os.Unsetenv("SEGMENTS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.MaxBody != 10_000_000 {
+		t.Errorf("expected MaxBody to be 10000000, got %v", cfg.MaxBody)
+	}
+	if cfg.Buffer != 512*1024 {
+		t.Errorf("expected Buffer to be 524288, got %v", cfg.Buffer)
+	}
+	if len(cfg.Segments) != 2 || cfg.Segments[0] != 1<<30 || cfg.Segments[1] != 512_000 {
+		t.Errorf("expected Segments to be [1073741824 512000], got %v", cfg.Segments)
+	}
+}
+
+func TestByteSizeOptionWithMinMax(t *testing.T) {
+	type Config struct {
+		MaxBody int64 `env:"name=MAX_BODY,bytesize,min=1024,max=1048576"`
+	}
+
+	os.Setenv("MAX_BODY", "2Mi")
+	defer os.Unsetenv("MAX_BODY")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for size above max, got none")
+	}
+}
+
+func TestByteSizeOptionOnStringField(t *testing.T) {
+	type Config struct {
+		MaxBody string `env:"name=MAX_BODY,bytesize"`
+	}
+
+	os.Setenv("MAX_BODY", "2Mi")
+	defer os.Unsetenv("MAX_BODY")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for bytesize on a string field, got none")
+	}
+}
+
+func TestGroupExactlyOne(t *testing.T) {
+	type Config struct {
+		HTTPAddr   string `env:"name=HTTP_ADDR,group=listeners"`
+		HTTPSAddr  string `env:"name=HTTPS_ADDR,group=listeners"`
+		UnixSocket string `env:"name=UNIX_SOCKET,group=listeners"`
+	}
+
+	os.Setenv("HTTPS_ADDR", ":8443")
+	defer os.Unsetenv("HTTPS_ADDR")
+
+	parser := env.NewParser().WithGroup("listeners", 1, 1)
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("UNIX_SOCKET", "/tmp/app.sock")
+	defer os.Unsetenv("UNIX_SOCKET")
+
+	err = parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for two set fields, got none")
+	}
+	if !strings.Contains(err.Error(), "HTTPS_ADDR, UNIX_SOCKET") {
+		t.Errorf("expected error to name the set variables, got %v", err)
+	}
+}
+
+func TestGroupMinimumNotMet(t *testing.T) {
+	type Config struct {
+		HTTPAddr  string `env:"name=HTTP_ADDR,group=listeners,default=:8080"`
+		HTTPSAddr string `env:"name=HTTPS_ADDR,group=listeners"`
+	}
+
+	os.Unsetenv("HTTP_ADDR")
+	os.Unsetenv("HTTPS_ADDR")
+
+	parser := env.NewParser().WithGroup("listeners", 1, env.NoGroupLimit)
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for no set fields, got none")
+	}
+}
+
+func TestGroupAcrossNestedStructs(t *testing.T) {
+	type Backend struct {
+		RedisURL string `env:"name=REDIS_URL,group=cache"`
+	}
+	type Config struct {
+		MemcachedAddr string `env:"name=MEMCACHED_ADDR,group=cache"`
+		Backend       Backend
+	}
+
+	os.Setenv("REDIS_URL", "redis://localhost")
+	os.Setenv("MEMCACHED_ADDR", "localhost:11211")
+	defer os.Unsetenv("REDIS_URL")
+	defer os.Unsetenv("MEMCACHED_ADDR")
+
+	parser := env.NewParser().WithGroup("cache", 0, 1)
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for two set fields, got none")
+	}
+}
+
+func TestGroupAtMostZero(t *testing.T) {
+	type Config struct {
+		Legacy string `env:"name=LEGACY_ADDR,group=legacy"`
+	}
+
+	parser := env.NewParser().WithGroup("legacy", 0, 0)
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("LEGACY_ADDR", ":9000")
+	defer os.Unsetenv("LEGACY_ADDR")
+
+	err := parser.Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "group 'legacy' requires no fields to be set, got 1 (LEGACY_ADDR)") {
+		t.Errorf("expected an error for a set field, got %v", err)
+	}
+}
+
+func TestStripBOMAndCR(t *testing.T) {
+	type Config struct {
+		Mode  string   `env:"name=MODE,v_aws_region"`
+		Token string   `env:"name=TOKEN,notrim"`
+		Hosts []string `env:"name=HOSTS"`
+	}
+
+	os.Setenv("MODE", "\uFEFFus-east-1\r")
+	os.Setenv("TOKEN", "\uFEFF abc \r")
+	os.Setenv("HOSTS", "a|b\r\n")
+	defer os.Unsetenv("MODE")
+	defer os.Unsetenv("TOKEN")
+	defer os.Unsetenv("HOSTS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Mode != "us-east-1" {
+		t.Errorf("expected Mode to be 'us-east-1', got %q", cfg.Mode)
+	}
+	if cfg.Token != " abc " {
+		t.Errorf("expected Token to be ' abc ', got %q", cfg.Token)
+	}
+	if len(cfg.Hosts) != 2 || cfg.Hosts[1] != "b" {
+		t.Errorf("expected Hosts to be [a b], got %q", cfg.Hosts)
+	}
+}
+
+func TestHardwareAddrValue(t *testing.T) {
+	type Config struct {
+		MAC     net.HardwareAddr   `env:"name=MAC"`
+		Allowed []net.HardwareAddr `env:"name=ALLOWED_MACS"`
+	}
+
+	os.Setenv("MAC", "00:1A:2B:3C:4D:5E")
+	os.Setenv("ALLOWED_MACS", "00:00:5e:00:53:01|00-00-5E-00-53-02")
+	defer os.Unsetenv("MAC")
+	defer os.Unsetenv("ALLOWED_MACS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.MAC.String() != "00:1a:2b:3c:4d:5e" {
+		t.Errorf("expected MAC to be '00:1a:2b:3c:4d:5e', got %v", cfg.MAC)
+	}
+	if len(cfg.Allowed) != 2 || cfg.Allowed[1].String() != "00:00:5e:00:53:02" {
+		t.Errorf("expected Allowed to have 2 addresses, got %v", cfg.Allowed)
+	}
+}
+
+func TestInvalidHardwareAddrValue(t *testing.T) {
+	type Config struct {
+		MAC net.HardwareAddr `env:"name=MAC"`
+	}
+
+	os.Setenv("MAC", "00:1A:2B")
+	defer os.Unsetenv("MAC")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for invalid MAC address, got none")
+	}
+}
+
+func TestValidMacValidator(t *testing.T) {
+	type Config struct {
+		MAC string `env:"name=MAC,v_mac"`
+	}
+
+	os.Setenv("MAC", "00:1A:2B:3C:4D:5E")
+	defer os.Unsetenv("MAC")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.MAC != "00:1A:2B:3C:4D:5E" {
+		t.Errorf("expected MAC to keep its textual form, got %v", cfg.MAC)
+	}
+}
+
+func TestInvalidMacValidator(t *testing.T) {
+	type Config struct {
+		MAC string `env:"name=MAC,v_mac"`
+	}
+
+	os.Setenv("MAC", "not-a-mac")
+	defer os.Unsetenv("MAC")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for invalid MAC address, got none")
+	}
+}
+
+func TestPercentOption(t *testing.T) {
+	type Config struct {
+		CPULimit  float64   `env:"name=CPU_LIMIT,percent,max=1"`
+		Threshold float32   `env:"name=THRESHOLD,percent=raw,default=90%"`
+		Rollout   int       `env:"name=ROLLOUT,percent=raw,min=0,max=100"`
+		Weights   []float64 `env:"name=WEIGHTS,percent"`
+	}
+
+	os.Setenv("CPU_LIMIT", "75%")
+	os.Setenv("ROLLOUT", "25%")
+	os.Setenv("WEIGHTS", "10%|90%")
+	defer os.Unsetenv("CPU_LIMIT")
+	defer os.Unsetenv("ROLLOUT")
+	defer os.Unsetenv("WEIGHTS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.CPULimit != 0.75 {
+		t.Errorf("expected CPULimit to be 0.75, got %v", cfg.CPULimit)
+	}
+	if cfg.Threshold != 90 {
+		t.Errorf("expected Threshold to be 90, got %v", cfg.Threshold)
+	}
+	if cfg.Rollout != 25 {
+		t.Errorf("expected Rollout to be 25, got %v", cfg.Rollout)
+	}
+	if len(cfg.Weights) != 2 || cfg.Weights[0] != 0.1 || cfg.Weights[1] != 0.9 {
+		t.Errorf("expected Weights to be [0.1 0.9], got %v", cfg.Weights)
+	}
+}
+
+func TestPercentOptionAboveMax(t *testing.T) {
+	type Config struct {
+		CPULimit float64 `env:"name=CPU_LIMIT,percent,max=1"`
+	}
+
+	os.Setenv("CPU_LIMIT", "150%")
+	defer os.Unsetenv("CPU_LIMIT")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for percentage above max, got none")
+	}
+}
+
+func TestPercentOptionOnIntField(t *testing.T) {
+	type Config struct {
+		Rollout int `env:"name=ROLLOUT,percent"`
+	}
+
+	os.Setenv("ROLLOUT", "25%")
+	defer os.Unsetenv("ROLLOUT")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for percent on an int field, got none")
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	type Database struct {
+		Password string `env:"name=DB_PASSWORD"`
+	}
+	type Config struct {
+		Port     int    `env:"name=PORT,default=8080"`
+		Host     string `env:"name=HOST"`
+		Mode     string `env:"name=MODE"`
+		Database Database
+	}
+
+	os.Setenv("HOST", "localhost")
+	os.Setenv("DB_PASSWORD", "exec:/bin/echo s3cret")
+	os.Unsetenv("PORT")
+	os.Unsetenv("MODE")
+	defer os.Unsetenv("HOST")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	var buf bytes.Buffer
+	parser := env.NewParser().WithExecAllowlist("/bin/echo")
+	var cfg Config
+	err := parser.PrintTable(&buf, &cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `FIELD              VARIABLE     VALUE      SOURCE   DEFAULT
+Port               PORT         8080       default  yes
+Host               HOST         localhost  env      no
+Mode               MODE                    none     no
+Database.Password  DB_PASSWORD  ***        env      no
+`
+	if buf.String() != expected {
+		t.Errorf("expected table:\n%s\ngot:\n%s", expected, buf.String())
+	}
+	if cfg.Database.Password != "s3cret" {
+		t.Errorf("expected Database.Password to be populated, got %v", cfg.Database.Password)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	type Config struct {
+		Port  int    `env:"name=PORT|LISTEN_PORT,default=8080"`
+		Host  string `env:"name=HOST|HOSTNAME"`
+		Token string `env:"name=TOKEN,sensitive"`
+		Mode  string `env:"name=MODE"`
+	}
+
+	os.Setenv("HOSTNAME", "example.com")
+	os.Setenv("TOKEN", "s3cret")
+	os.Unsetenv("HOST")
+	os.Unsetenv("PORT")
+	os.Unsetenv("LISTEN_PORT")
+	os.Unsetenv("MODE")
+	defer os.Unsetenv("HOSTNAME")
+	defer os.Unsetenv("TOKEN")
+
+	var cfg Config
+	res, err := env.NewParser().Resolve(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []env.Resolution{
+		{Field: "Port", Value: "8080", Source: env.SourceDefault, Default: true},
+		{Field: "Host", Var: "HOSTNAME", Value: "example.com", Source: env.SourceEnv},
+		{Field: "Token", Var: "TOKEN", Value: "***", Source: env.SourceEnv},
+		{Field: "Mode", Source: env.SourceNone},
+	}
+	if len(res) != len(expected) {
+		t.Fatalf("expected %d resolutions, got %+v", len(expected), res)
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], res[i])
+		}
+	}
+	if cfg.Token != "s3cret" || cfg.Port != 8080 {
+		t.Errorf("expected the struct to be populated, got %+v", cfg)
+	}
+}
+
+func TestMarshalResolvedJSON(t *testing.T) {
+	type Config struct {
+		Port  int    `env:"name=PORT,default=8080"`
+		Token string `env:"name=TOKEN,sensitive"`
+	}
+
+	os.Unsetenv("PORT")
+	os.Setenv("TOKEN", "s3cret")
+	defer os.Unsetenv("TOKEN")
+
+	var cfg Config
+	b, err := env.NewParser().MarshalResolvedJSON(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := `[{"field":"Port","value":"8080","source":"default","default":true},{"field":"Token","var":"TOKEN","value":"***","source":"env","default":false}]`
+	if string(b) != expected {
+		t.Errorf("expected JSON:\n%s\ngot:\n%s", expected, b)
+	}
+}
+
+type EmbeddedObservability struct {
+	Tracing EmbeddedTracing `env:"flatten"`
+	Level   string          `env:"name=LOG_LEVEL"`
+}
+
+type EmbeddedTracing struct {
+	Endpoint string `env:"name=OTEL_EXPORTER_OTLP_ENDPOINT"`
+}
+
+func TestFlattenNestedStruct(t *testing.T) {
+	type Config struct {
+		EmbeddedObservability `env:"prefix=OBS_"`
+	}
+
+	os.Setenv("APP_OBS_LOG_LEVEL", "debug")
+	os.Setenv("APP_OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	defer os.Unsetenv("APP_OBS_LOG_LEVEL")
+	defer os.Unsetenv("APP_OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	parser := env.NewParser().WithNamePrefix("APP_")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Level != "debug" {
+		t.Errorf("expected Level to be 'debug', got %v", cfg.Level)
+	}
+	if cfg.Tracing.Endpoint != "http://collector:4317" {
+		t.Errorf("expected Tracing.Endpoint to be 'http://collector:4317', got %v", cfg.Tracing.Endpoint)
+	}
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCoverage(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=HOST,default=localhost"`
+		Port int    `env:"name=PORT,default=8080"`
+	}
+
+	cov := env.NewCoverage()
+	parser := env.NewParser().WithCoverage(cov)
+
+	os.Setenv("HOST", "example.com")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	os.Unsetenv("HOST")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if uncovered := cov.Uncovered(); len(uncovered) != 1 || uncovered[0] != "env_test.Config.Port" {
+		t.Errorf("expected Port to be uncovered, got %v", uncovered)
+	}
+	ft := &fakeT{}
+	cov.AssertComplete(ft)
+	if len(ft.errors) != 1 {
+		t.Errorf("expected an assertion error, got %v", ft.errors)
+	}
+
+	os.Setenv("PORT", "9090")
+	err = parser.Unmarshal(&cfg)
+	os.Unsetenv("PORT")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ft = &fakeT{}
+	cov.AssertComplete(ft)
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no assertion error, got %v", ft.errors)
+	}
+	if covered := cov.Covered(); len(covered) != 2 {
+		t.Errorf("expected 2 covered fields, got %v", covered)
+	}
+}
+
+func TestRegexOption(t *testing.T) {
+	type Config struct {
+		Slug  string   `env:"name=SLUG,regex=^[a-z0-9-]+$"`
+		Zones []string `env:"name=ZONES,regex=^[a-z]{2}-[a-z]+$"`
+	}
+
+	os.Setenv("SLUG", "my-service-01")
+	os.Setenv("ZONES", "eu-west|us-east")
+	defer os.Unsetenv("SLUG")
+	defer os.Unsetenv("ZONES")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Slug != "my-service-01" {
+		t.Errorf("expected Slug to be 'my-service-01', got %v", cfg.Slug)
+	}
+	if len(cfg.Zones) != 2 {
+		t.Errorf("expected 2 zones, got %v", cfg.Zones)
+	}
+}
+
+func TestRegexOptionMismatch(t *testing.T) {
+	type Config struct {
+		Slug string `env:"name=SLUG,regex=^[a-z0-9-]+$"`
+	}
+
+	os.Setenv("SLUG", "My_Service")
+	defer os.Unsetenv("SLUG")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for mismatching value, got none")
+	}
+}
+
+func TestRegexOptionSliceElementMismatch(t *testing.T) {
+	type Config struct {
+		Zones []string `env:"name=ZONES,regex=^[a-z]{2}-[a-z]+$"`
+	}
+
+	os.Setenv("ZONES", "eu-west|US-EAST")
+	defer os.Unsetenv("ZONES")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for mismatching element, got none")
+	}
+}
+
+func TestRegexOptionInvalidPattern(t *testing.T) {
+	type Config struct {
+		Slug string `env:"name=SLUG,regex=^[a-z"`
+	}
+
+	os.Setenv("SLUG", "abc")
+	defer os.Unsetenv("SLUG")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for invalid pattern, got none")
+	}
+}
+
+func TestRegexOptionWithCustomSeparator(t *testing.T) {
+	type Config struct {
+		Code string `env:"name=CODE;regex=^[A-Z]{2,3}$"`
+	}
+
+	os.Setenv("CODE", "ABC")
+	defer os.Unsetenv("CODE")
+
+	parser := env.NewParser().WithTagOptionSeparator(";")
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestTruthyFalsyOptions(t *testing.T) {
+	type Config struct {
+		Feature  bool   `env:"name=FEATURE,truthy=enabled|on,falsy=disabled|off"`
+		Legacy   bool   `env:"name=LEGACY,truthy=enabled|on,falsy=disabled|off"`
+		Standard bool   `env:"name=STANDARD,truthy=enabled"`
+		Flags    []bool `env:"name=FLAGS,truthy=yes,falsy=no"`
+	}
+
+	os.Setenv("FEATURE", "Enabled")
+	os.Setenv("LEGACY", "off")
+	os.Setenv("STANDARD", "true")
+	os.Setenv("FLAGS", "yes|no|1")
+	defer os.Unsetenv("FEATURE")
+	defer os.Unsetenv("LEGACY")
+	defer os.Unsetenv("STANDARD")
+	defer os.Unsetenv("FLAGS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Feature {
+		t.Errorf("expected Feature to be true")
+	}
+	if cfg.Legacy {
+		t.Errorf("expected Legacy to be false")
+	}
+	if !cfg.Standard {
+		t.Errorf("expected Standard to be true")
+	}
+	if len(cfg.Flags) != 3 || !cfg.Flags[0] || cfg.Flags[1] || !cfg.Flags[2] {
+		t.Errorf("expected Flags to be [true false true], got %v", cfg.Flags)
+	}
+}
+
+func TestTruthyFalsyUnknownWord(t *testing.T) {
+	type Config struct {
+		Feature bool `env:"name=FEATURE,truthy=enabled,falsy=disabled"`
+	}
+
+	os.Setenv("FEATURE", "on")
+	defer os.Unsetenv("FEATURE")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown word, got none")
+	}
+}
+
+func TestOneOfOption(t *testing.T) {
+	type Config struct {
+		LogLevel string   `env:"name=LOG_LEVEL,lower,oneof=debug|info|warn|error,default=info"`
+		Workers  int      `env:"name=WORKERS,oneof=1|2|4|8"`
+		Regions  []string `env:"name=REGIONS,oneof=eu|us|ap"`
+	}
+
+	os.Setenv("LOG_LEVEL", "WARN")
+	os.Setenv("WORKERS", "4")
+	os.Setenv("REGIONS", "eu|ap")
+	defer os.Unsetenv("LOG_LEVEL")
+	defer os.Unsetenv("WORKERS")
+	defer os.Unsetenv("REGIONS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.LogLevel != "warn" {
+		t.Errorf("expected LogLevel to be 'warn', got %v", cfg.LogLevel)
+	}
+	if cfg.Workers != 4 {
+		t.Errorf("expected Workers to be 4, got %v", cfg.Workers)
+	}
+	if len(cfg.Regions) != 2 {
+		t.Errorf("expected 2 regions, got %v", cfg.Regions)
+	}
+}
+
+func TestOneOfOptionInvalid(t *testing.T) {
+	type Config struct {
+		LogLevel string `env:"name=LOG_LEVEL,oneof=debug|info|warn|error"`
+	}
+
+	os.Setenv("LOG_LEVEL", "trace")
+	defer os.Unsetenv("LOG_LEVEL")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for a value not in the set, got none")
+	}
+	if !strings.Contains(err.Error(), "debug, info, warn, error") {
+		t.Errorf("expected error to list allowed values, got %v", err)
+	}
+}
+
+func TestOneOfOptionInvalidSliceElement(t *testing.T) {
+	type Config struct {
+		Regions []string `env:"name=REGIONS,oneof=eu|us|ap"`
+	}
+
+	os.Setenv("REGIONS", "eu|sa")
+	defer os.Unsetenv("REGIONS")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for an element not in the set, got none")
+	}
+}
+
+func TestRequiredIfFieldIsSet(t *testing.T) {
+	type TLS struct {
+		Enabled  bool   `env:"name=TLS_ENABLED,default=false"`
+		CertFile string `env:"name=TLS_CERT_FILE,required_if=Enabled=true"`
+	}
+	type Config struct {
+		TLS TLS
+	}
+
+	os.Setenv("TLS_ENABLED", "true")
+	defer os.Unsetenv("TLS_ENABLED")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for missing conditionally required field, got none")
+	}
+
+	os.Setenv("TLS_CERT_FILE", "/etc/tls/cert.pem")
+	defer os.Unsetenv("TLS_CERT_FILE")
+
+	err = parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRequiredIfConditionNotMet(t *testing.T) {
+	type Config struct {
+		CertFile   string `env:"name=TLS_CERT_FILE,required_if=TLSEnabled=true"`
+		TLSEnabled bool   `env:"name=TLS_ENABLED,default=false"`
+	}
+
+	os.Unsetenv("TLS_ENABLED")
+	os.Unsetenv("TLS_CERT_FILE")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRequiredIfEnvVarIsSet(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,required_if=DB_USER"`
+	}
+
+	os.Setenv("DB_USER", "admin")
+	defer os.Unsetenv("DB_USER")
+
+	parser := env.NewParser()
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for missing conditionally required field, got none")
+	}
+}
+
+func TestJSONOption(t *testing.T) {
+	type Backend struct {
+		Host   string `json:"host"`
+		Weight int    `json:"weight"`
+	}
+	type Config struct {
+		Backends []Backend         `env:"name=BACKENDS,json"`
+		Primary  Backend           `env:"name=PRIMARY,json"`
+		Labels   map[string]string `env:"name=LABELS,json"`
+	}
+
+	os.Setenv("BACKENDS", `[{"host":"a","weight":1},{"host":"b","weight":2}]`)
+	os.Setenv("PRIMARY", `{"host":"a","weight":1}`)
+	defer os.Unsetenv("BACKENDS")
+	defer os.Unsetenv("PRIMARY")
+	os.Unsetenv("LABELS")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.Backends) != 2 || cfg.Backends[1].Host != "b" || cfg.Backends[1].Weight != 2 {
+		t.Errorf("unexpected backends: %+v", cfg.Backends)
+	}
+	if cfg.Primary.Host != "a" {
+		t.Errorf("unexpected primary: %+v", cfg.Primary)
+	}
+	if cfg.Labels != nil {
+		t.Errorf("expected nil labels, got %v", cfg.Labels)
+	}
+}
+
+func TestJSONOptionInvalid(t *testing.T) {
+	type Config struct {
+		Labels map[string]string `env:"name=LABELS,json"`
+	}
+
+	os.Setenv("LABELS", `{"a":`)
+	defer os.Unsetenv("LABELS")
+
+	var cfg Config
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Config.Labels (LABELS): invalid JSON value") {
+		t.Fatalf("expected invalid JSON error, got %v", err)
+	}
+}
+
+func TestUnsupportedSliceElementType(t *testing.T) {
+	type Backend struct {
+		Host string
+	}
+	type Config struct {
+		Backends []Backend `env:"name=BACKENDS"`
+	}
+
+	os.Setenv("BACKENDS", "a,b")
+	defer os.Unsetenv("BACKENDS")
+
+	var cfg Config
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "unsupported slice element type env_test.Backend") || !strings.Contains(err.Error(), "json option") {
+		t.Fatalf("expected unsupported slice element type error, got %v", err)
+	}
+}
+
+func TestXorGroup(t *testing.T) {
+	type Config struct {
+		Password     string `env:"name=PASSWORD,xor=password"`
+		PasswordFile string `env:"name=PASSWORD_FILE,xor=password"`
+	}
+
+	os.Setenv("PASSWORD", "secret")
+	defer os.Unsetenv("PASSWORD")
+	os.Unsetenv("PASSWORD_FILE")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("PASSWORD_FILE", "/run/secrets/password")
+	defer os.Unsetenv("PASSWORD_FILE")
+
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected an error for mutually exclusive fields, got none")
+	}
+	if !strings.Contains(err.Error(), "PASSWORD, PASSWORD_FILE") || !strings.Contains(err.Error(), "'password'") {
+		t.Errorf("expected error to name the conflicting variables, got %v", err)
+	}
+}
+
+func TestXorGroupIgnoresDefaults(t *testing.T) {
+	type Config struct {
+		Token     string `env:"name=TOKEN,xor=token,default=dev"`
+		TokenFile string `env:"name=TOKEN_FILE,xor=token"`
+	}
+
+	os.Unsetenv("TOKEN")
+	os.Setenv("TOKEN_FILE", "/run/secrets/token")
+	defer os.Unsetenv("TOKEN_FILE")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestExpandOption(t *testing.T) {
+	type Config struct {
+		LogDir   string `env:"name=LOG_DIR,expand"`
+		CacheDir string `env:"name=CACHE_DIR,default=${SPEC_TMPDIR}/cache,expand"`
+		Raw      string `env:"name=RAW_DIR"`
+	}
+
+	os.Setenv("SPEC_HOME", "/home/app")
+	os.Setenv("SPEC_TMPDIR", "/tmp")
+	os.Setenv("LOG_DIR", "${SPEC_HOME}/logs")
+	os.Setenv("RAW_DIR", "$SPEC_HOME/raw")
+	defer os.Unsetenv("SPEC_HOME")
+	defer os.Unsetenv("SPEC_TMPDIR")
+	defer os.Unsetenv("LOG_DIR")
+	defer os.Unsetenv("RAW_DIR")
+	os.Unsetenv("CACHE_DIR")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.LogDir != "/home/app/logs" {
+		t.Errorf("expected LogDir to be '/home/app/logs', got '%s'", cfg.LogDir)
+	}
+	if cfg.CacheDir != "/tmp/cache" {
+		t.Errorf("expected CacheDir to be '/tmp/cache', got '%s'", cfg.CacheDir)
+	}
+	if cfg.Raw != "$SPEC_HOME/raw" {
+		t.Errorf("expected Raw to be unexpanded, got '%s'", cfg.Raw)
+	}
+
+	if err := env.NewParser().WithExpand(true).Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Raw != "/home/app/raw" {
+		t.Errorf("expected Raw to be '/home/app/raw', got '%s'", cfg.Raw)
+	}
+}
+
+func TestFileOption(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD_FILE,file,required"`
+		Token    string `env:"name=TOKEN_FILE,file"`
+	}
+
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("s3cret\r\n"), 0o600); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("DB_PASSWORD_FILE", path)
+	defer os.Unsetenv("DB_PASSWORD_FILE")
+	os.Unsetenv("TOKEN_FILE")
+
+	var cfg Config
+	var buf bytes.Buffer
+	if err := env.NewParser().PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" {
+		t.Errorf("expected Password to be 's3cret', got '%s'", cfg.Password)
+	}
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("expected the file content to be masked, got:\n%s", buf.String())
+	}
+
+	os.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	if err := env.NewParser().Unmarshal(&cfg); err == nil {
+		t.Fatalf("expected an error for a missing file, got none")
+	}
+}
+
+func TestUnsetOption(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD|DB_PASS,unset"`
+		User     string `env:"name=DB_USER"`
+	}
+
+	os.Setenv("DB_PASS", "secret")
+	os.Setenv("DB_USER", "admin")
+	defer os.Unsetenv("DB_PASS")
+	defer os.Unsetenv("DB_USER")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "secret" {
+		t.Errorf("expected Password to be 'secret', got '%s'", cfg.Password)
+	}
+	if _, ok := os.LookupEnv("DB_PASS"); ok {
+		t.Errorf("expected DB_PASS to be removed from the environment")
+	}
+	if os.Getenv("DB_USER") != "admin" {
+		t.Errorf("expected DB_USER to be kept in the environment")
+	}
+}
+
+func TestUnsetOptionKeepsVariablesOnError(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,unset"`
+		Port     int    `env:"name=DB_PORT"`
+	}
+
+	os.Setenv("DB_PASSWORD", "secret")
+	os.Setenv("DB_PORT", "invalid")
+	defer os.Unsetenv("DB_PASSWORD")
+	defer os.Unsetenv("DB_PORT")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err == nil {
+		t.Fatalf("expected an error for an invalid port, got none")
+	}
+	if os.Getenv("DB_PASSWORD") != "secret" {
+		t.Errorf("expected DB_PASSWORD to be kept when decoding fails")
+	}
+}
+
+func TestSensitiveOptionRedactsErrors(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,sensitive,regex=^[a-z]+$"`
+	}
+
+	os.Setenv("DB_PASSWORD", "Sup3rSecret")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	var cfg Config
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected a validation error, got none")
+	}
+	if strings.Contains(err.Error(), "Sup3rSecret") || !strings.Contains(err.Error(), "***") {
+		t.Errorf("expected the value to be redacted, got %v", err)
+	}
+}
+
+func TestSensitiveOptionRedactsSliceElements(t *testing.T) {
+	type Config struct {
+		Pins []int `env:"name=PINS,sensitive"`
+	}
+
+	os.Setenv("PINS", "1234|12a4")
+	defer os.Unsetenv("PINS")
+
+	var cfg Config
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil {
+		t.Fatalf("expected a parse error, got none")
+	}
+	if strings.Contains(err.Error(), "12a4") {
+		t.Errorf("expected the element to be redacted, got %v", err)
+	}
+}
+
+func TestSensitiveOptionMasksTable(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,sensitive"`
+	}
+
+	os.Setenv("DB_PASSWORD", "Sup3rSecret")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	var cfg Config
+	var buf bytes.Buffer
+	if err := env.NewParser().PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Contains(buf.String(), "Sup3rSecret") || !strings.Contains(buf.String(), "***") {
+		t.Errorf("expected the value to be masked, got:\n%s", buf.String())
+	}
+	if cfg.Password != "Sup3rSecret" {
+		t.Errorf("expected Password to be 'Sup3rSecret', got '%s'", cfg.Password)
+	}
+}
+
+func TestSecretSourcesRedacted(t *testing.T) {
+	type Config struct {
+		Pin   int    `env:"name=PIN,file,regex=^[0-9]+$"`
+		Token string `env:"name=TOKEN"`
+		Host  string `env:"name=HOST"`
+	}
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "pin")
+	if err := os.WriteFile(secret, []byte("Sup3rSecret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("t0ken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("SECRETSRC_PIN", secret)
+	os.Setenv("SECRETSRC_TOKEN_FILE", token)
+	os.Setenv("SECRETSRC_HOST", "db")
+	defer os.Unsetenv("SECRETSRC_PIN")
+	defer os.Unsetenv("SECRETSRC_TOKEN_FILE")
+	defer os.Unsetenv("SECRETSRC_HOST")
+
+	var cfg Config
+	parser := env.NewParser().WithNamePrefix("SECRETSRC_").WithFileVariants(true)
+	err := parser.Unmarshal(&cfg)
+	var ve *env.ValidationError
+	if !errors.As(err, &ve) || strings.Contains(err.Error(), "Sup3rSecret") || ve.Value != "***" {
+		t.Fatalf("expected the value read from the file to be redacted, got %v", err)
+	}
+
+	if err := os.WriteFile(secret, []byte("1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	dump := parser.Dump(&cfg)
+	if strings.Contains(dump, "1234") || strings.Contains(dump, "t0ken") || !strings.Contains(dump, "Host: db") {
+		t.Errorf("expected the values of secret sources to be masked, got:\n%s", dump)
+	}
+}
+
+func TestDeprecatedOption(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASS,deprecated=DB_PASSWORD"`
+	}
+
+	os.Setenv("DB_PASS", "old")
+	defer os.Unsetenv("DB_PASS")
+	os.Unsetenv("DB_PASSWORD")
+
+	var warnings []string
+	parser := env.NewParser().WithWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+
+	var cfg Config
+	var buf bytes.Buffer
+	if err := parser.PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "old" {
+		t.Errorf("expected Password to be 'old', got '%s'", cfg.Password)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "DB_PASS is deprecated, rename it to DB_PASSWORD") {
+		t.Errorf("expected a deprecation warning, got %v", warnings)
+	}
+	if !strings.Contains(buf.String(), "DB_PASS (deprecated, use DB_PASSWORD)") {
+		t.Errorf("expected the table to mark the variable as deprecated, got:\n%s", buf.String())
+	}
+
+	os.Setenv("DB_PASSWORD", "new")
+	defer os.Unsetenv("DB_PASSWORD")
+	warnings = nil
+
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "new" {
+		t.Errorf("expected Password to be 'new', got '%s'", cfg.Password)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestNestedStructWithPrefix(t *testing.T) {
+	type Config struct {
+		Primary EmbeddedDatabase `env:"prefix=PRIMARY_"`
+		Replica EmbeddedDatabase `env:"prefix=REPLICA_"`
+		Cache   struct {
+			Server EmbeddedServer `env:"prefix=SERVER_"`
+		} `env:"prefix=CACHE_"`
+	}
+
+	os.Setenv("APP_PRIMARY_HOST", "primary")
+	os.Setenv("APP_REPLICA_HOST", "replica")
+	os.Setenv("APP_REPLICA_PORT", "5433")
+	os.Setenv("APP_CACHE_SERVER_HOST", "cache")
+	defer os.Unsetenv("APP_PRIMARY_HOST")
+	defer os.Unsetenv("APP_REPLICA_HOST")
+	defer os.Unsetenv("APP_REPLICA_PORT")
+	defer os.Unsetenv("APP_CACHE_SERVER_HOST")
+
+	var cfg Config
+	if err := env.NewParser().WithNamePrefix("APP_").Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Primary.DbHost != "primary" || cfg.Primary.DbPort != 5432 {
+		t.Errorf("unexpected primary: %+v", cfg.Primary)
+	}
+	if cfg.Replica.DbHost != "replica" || cfg.Replica.DbPort != 5433 {
+		t.Errorf("unexpected replica: %+v", cfg.Replica)
+	}
+	if cfg.Cache.Server.Host != "cache" {
+		t.Errorf("expected Cache.Server.Host to be 'cache', got '%s'", cfg.Cache.Server.Host)
+	}
+}
+
+func TestNotEmptyOption(t *testing.T) {
+	type Config struct {
+		Region string `env:"name=REGION,notempty,default=us-east-1"`
+	}
+
+	os.Unsetenv("REGION")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error for an unset variable, got %v", err)
+	}
+	if cfg.Region != "us-east-1" {
+		t.Errorf("expected Region to be 'us-east-1', got '%s'", cfg.Region)
+	}
+
+	os.Setenv("REGION", "  ")
+	defer os.Unsetenv("REGION")
+
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Config.Region (REGION): variable is set but empty") {
+		t.Fatalf("expected an error for an empty variable, got %v", err)
+	}
+}
+
+func TestEmptyIsSet(t *testing.T) {
+	type Config struct {
+		CacheDir string `env:"name=CACHE_DIR,default=/tmp/cache"`
+		Workers  int    `env:"name=WORKERS,default=4"`
+		Region   string `env:"name=REGION,default=us-east-1"`
+	}
+
+	os.Setenv("CACHE_DIR", "")
+	os.Setenv("WORKERS", "")
+	defer os.Unsetenv("CACHE_DIR")
+	defer os.Unsetenv("WORKERS")
+	os.Unsetenv("REGION")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.CacheDir != "/tmp/cache" || cfg.Workers != 4 {
+		t.Errorf("expected empty variables to use the defaults, got %+v", cfg)
+	}
+
+	cfg = Config{}
+	var buf bytes.Buffer
+	if err := env.NewParser().WithEmptyIsSet(true).PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.CacheDir != "" || cfg.Workers != 0 {
+		t.Errorf("expected empty variables to be kept, got %+v", cfg)
+	}
+	if cfg.Region != "us-east-1" {
+		t.Errorf("expected unset variables to use the default, got '%s'", cfg.Region)
+	}
+	if !strings.Contains(buf.String(), "CACHE_DIR") || strings.Contains(buf.String(), "/tmp/cache") {
+		t.Errorf("expected the table to show the empty value, got:\n%s", buf.String())
+	}
+}
+
+func TestTrimOptions(t *testing.T) {
+	type Config struct {
+		Quoted string   `env:"name=QUOTED,trim=\"'"`
+		ID     string   `env:"name=ID,trimprefix=id-,trimsuffix=.json"`
+		Tags   []string `env:"name=TAGS,trim=\""`
+	}
+
+	os.Setenv("QUOTED", ` "value" `)
+	os.Setenv("ID", "id-1234.json")
+	os.Setenv("TAGS", `"a"|"b"`)
+	defer os.Unsetenv("QUOTED")
+	defer os.Unsetenv("ID")
+	defer os.Unsetenv("TAGS")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Quoted != "value" {
+		t.Errorf("expected Quoted to be 'value', got '%s'", cfg.Quoted)
+	}
+	if cfg.ID != "1234" {
+		t.Errorf("expected ID to be '1234', got '%s'", cfg.ID)
+	}
+	if len(cfg.Tags) != 2 || cfg.Tags[0] != "a" || cfg.Tags[1] != "b" {
+		t.Errorf("expected Tags to be [a b], got %v", cfg.Tags)
+	}
+}
+
+func TestQuotedSliceOption(t *testing.T) {
+	type Config struct {
+		Tags  []string `env:"name=TAGS,quoted"`
+		Plain []string `env:"name=PLAIN"`
+	}
+
+	os.Setenv("TAGS", `"a|b"|c\|d| e `)
+	os.Setenv("PLAIN", `"a|b"`)
+	defer os.Unsetenv("TAGS")
+	defer os.Unsetenv("PLAIN")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := []string{"a|b", "c|d", "e"}
+	if fmt.Sprint(cfg.Tags) != fmt.Sprint(expected) {
+		t.Errorf("expected Tags to be %v, got %v", expected, cfg.Tags)
+	}
+	if len(cfg.Plain) != 2 {
+		t.Errorf("expected Plain to have 2 elements, got %v", cfg.Plain)
+	}
+
+	os.Setenv("TAGS", `"a|b`)
+	if err := env.NewParser().Unmarshal(&cfg); err == nil {
+		t.Fatalf("expected an error for an unterminated quote, got none")
+	}
+}
+
+func TestSkipFieldTag(t *testing.T) {
+	type Config struct {
+		Host     string           `env:"-"`
+		Database EmbeddedDatabase `env:"-"`
+		Port     int              `env:"name=PORT"`
+	}
+
+	os.Setenv("Host", "from-env")
+	os.Setenv("HOST", "from-env")
+	os.Setenv("PORT", "8080")
+	defer os.Unsetenv("Host")
+	defer os.Unsetenv("HOST")
+	defer os.Unsetenv("PORT")
+
+	cfg := Config{Host: "kept"}
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "kept" {
+		t.Errorf("expected Host to be untouched, got '%s'", cfg.Host)
+	}
+	if cfg.Database.DbHost != "" || cfg.Database.DbPort != 0 {
+		t.Errorf("expected Database to be untouched, got %+v", cfg.Database)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+	}
+}
+
+func TestComparisonOptions(t *testing.T) {
+	type Config struct {
+		Workers int     `env:"name=WORKERS,gt=0,lt=100"`
+		Ratio   float64 `env:"name=RATIO,gte=0,lte=1"`
+	}
+
+	tests := []struct {
+		workers, ratio string
+		wantErr        string
+	}{
+		{"1", "0", ""},
+		{"99", "1", ""},
+		{"0", "0.5", "Config.Workers (WORKERS): value 0 must be greater than 0"},
+		{"100", "0.5", "Config.Workers (WORKERS): value 100 must be less than 100"},
+		{"5", "-0.1", "Config.Ratio (RATIO): value -0.1 must be greater than or equal to 0"},
+		{"5", "1.5", "Config.Ratio (RATIO): value 1.5 must be less than or equal to 1"},
+	}
+
+	defer os.Unsetenv("WORKERS")
+	defer os.Unsetenv("RATIO")
+	for _, tt := range tests {
+		os.Setenv("WORKERS", tt.workers)
+		os.Setenv("RATIO", tt.ratio)
+
+		var cfg Config
+		err := env.NewParser().Unmarshal(&cfg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("WORKERS=%s RATIO=%s: expected no error, got %v", tt.workers, tt.ratio, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("WORKERS=%s RATIO=%s: expected error %q, got %v", tt.workers, tt.ratio, tt.wantErr, err)
+		}
+	}
+}
+
+func TestComparisonOptionsLargeIntegers(t *testing.T) {
+	type Config struct {
+		Signed   int64  `env:"name=SIGNED,lt=9223372036854775807"`
+		Unsigned uint64 `env:"name=UNSIGNED,gt=18446744073709551614"`
+	}
+
+	os.Setenv("SIGNED", "9223372036854775806")
+	os.Setenv("UNSIGNED", "18446744073709551615")
+	defer os.Unsetenv("SIGNED")
+	defer os.Unsetenv("UNSIGNED")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("SIGNED", "9223372036854775807")
+	if err := env.NewParser().Unmarshal(&cfg); err == nil {
+		t.Errorf("expected an error for a value equal to the lt bound, got none")
+	}
+
+	os.Setenv("SIGNED", "1")
+	os.Setenv("UNSIGNED", "18446744073709551614")
+	if err := env.NewParser().Unmarshal(&cfg); err == nil {
+		t.Errorf("expected an error for a value equal to the gt bound, got none")
+	}
+}
+
+func TestMultipleOfOption(t *testing.T) {
+	type Config struct {
+		BufferSize int    `env:"name=BUFFER_SIZE,bytesize,multipleof=512"`
+		Alignment  uint64 `env:"name=ALIGNMENT,multipleof=8"`
+	}
+
+	os.Setenv("BUFFER_SIZE", "4KiB")
+	os.Setenv("ALIGNMENT", "64")
+	defer os.Unsetenv("BUFFER_SIZE")
+	defer os.Unsetenv("ALIGNMENT")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.BufferSize != 4096 || cfg.Alignment != 64 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	os.Setenv("BUFFER_SIZE", "1000")
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "value 1000 is not a multiple of 512") {
+		t.Errorf("expected a multipleof error, got %v", err)
+	}
+}
+
+func TestMultipleOfOptionOnFloat(t *testing.T) {
+	type Config struct {
+		Ratio float64 `env:"name=RATIO,multipleof=2"`
+	}
+
+	os.Setenv("RATIO", "4")
+	defer os.Unsetenv("RATIO")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err == nil {
+		t.Errorf("expected an error for multipleof on a float field, got none")
+	}
+}
+
+func TestLenAlphanumASCIIOptions(t *testing.T) {
+	type Config struct {
+		Token string   `env:"name=TOKEN,len=12,alphanum"`
+		Label string   `env:"name=LABEL,ascii"`
+		Codes []string `env:"name=CODES,len=2"`
+	}
+
+	tests := []struct {
+		token, label, codes string
+		wantErr             bool
+	}{
+		{"abcDEF123456", "hello world!", "de|fr", false},
+		{"abcDEF12345", "hello", "de", true},
+		{"abcDEF12345-", "hello", "de", true},
+		{"abcDEF123456", "héllo", "de", true},
+		{"abcDEF123456", "hello", "de|fra", true},
+	}
+
+	defer os.Unsetenv("TOKEN")
+	defer os.Unsetenv("LABEL")
+	defer os.Unsetenv("CODES")
+	for _, tt := range tests {
+		os.Setenv("TOKEN", tt.token)
+		os.Setenv("LABEL", tt.label)
+		os.Setenv("CODES", tt.codes)
+
+		var cfg Config
+		err := env.NewParser().Unmarshal(&cfg)
+		if tt.wantErr && err == nil {
+			t.Errorf("TOKEN=%s LABEL=%s CODES=%s: expected an error, got none", tt.token, tt.label, tt.codes)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("TOKEN=%s LABEL=%s CODES=%s: expected no error, got %v", tt.token, tt.label, tt.codes, err)
+		}
+	}
+}
+
+func TestUniqueAndSortedOptions(t *testing.T) {
+	type Config struct {
+		Hosts   []string `env:"name=HOSTS,unique=dedupe,sorted"`
+		Ports   []int    `env:"name=PORTS,sorted"`
+		Regions []string `env:"name=REGIONS,unique"`
+	}
+
+	os.Setenv("HOSTS", "c|a|b|a")
+	os.Setenv("PORTS", "443|80|8080")
+	os.Setenv("REGIONS", "eu-west-1|us-east-1")
+	defer os.Unsetenv("HOSTS")
+	defer os.Unsetenv("PORTS")
+	defer os.Unsetenv("REGIONS")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if fmt.Sprint(cfg.Hosts) != "[a b c]" {
+		t.Errorf("expected Hosts to be [a b c], got %v", cfg.Hosts)
+	}
+	if fmt.Sprint(cfg.Ports) != "[80 443 8080]" {
+		t.Errorf("expected Ports to be [80 443 8080], got %v", cfg.Ports)
+	}
+
+	os.Setenv("REGIONS", "eu-west-1|us-east-1|eu-west-1")
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "duplicate value in list: eu-west-1") {
+		t.Errorf("expected a duplicate value error, got %v", err)
+	}
+}
+
+func TestNonZeroOption(t *testing.T) {
+	type Config struct {
+		Port     int           `env:"name=PORT,default=8080,nonzero"`
+		Interval time.Duration `env:"name=INTERVAL,default=30s,nonzero"`
+		Ratio    float64       `env:"name=RATIO,default=0.5,nonzero"`
+	}
+
+	os.Unsetenv("PORT")
+	os.Unsetenv("INTERVAL")
+	os.Unsetenv("RATIO")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, tc := range []struct{ name, val, wantErr string }{
+		{"PORT", "0", "Config.Port (PORT): value 0 must not be zero"},
+		{"INTERVAL", "0s", "Config.Interval (INTERVAL): value 0s must not be zero"},
+		{"RATIO", "0.0", "Config.Ratio (RATIO): value 0 must not be zero"},
+	} {
+		os.Setenv(tc.name, tc.val)
+		err := env.NewParser().Unmarshal(&cfg)
+		if err == nil || err.Error() != tc.wantErr {
+			t.Errorf("%s=%s: expected error %q, got %v", tc.name, tc.val, tc.wantErr, err)
+		}
+		os.Unsetenv(tc.name)
+	}
+}
+
+func TestDefaultEnvOption(t *testing.T) {
+	type Config struct {
+		Port int `env:"name=PORT,defaultenv=PLATFORM_PORT,default=8080"`
+	}
+
+	os.Unsetenv("APP_PORT")
+	os.Unsetenv("PLATFORM_PORT")
+
+	parser := env.NewParser().WithNamePrefix("APP_")
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+	}
+
+	os.Setenv("PLATFORM_PORT", "3000")
+	defer os.Unsetenv("PLATFORM_PORT")
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Port != 3000 {
+		t.Errorf("expected Port to be 3000, got %d", cfg.Port)
+	}
+
+	os.Setenv("APP_PORT", "9000")
+	defer os.Unsetenv("APP_PORT")
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("expected Port to be 9000, got %d", cfg.Port)
+	}
+}
+
+func TestDefaultIfOption(t *testing.T) {
+	type Config struct {
+		AppEnv   string `env:"name=APP_ENV,default=development"`
+		LogLevel string `env:"name=LOG_LEVEL,default_if=AppEnv=production:warn|AppEnv=staging:info,default=debug"`
+		Profiler bool   `env:"name=PROFILER,default_if=PROFILER_ADDR:true,default=false"`
+	}
+
+	tests := []struct {
+		appEnv, profilerAddr string
+		wantLevel            string
+		wantProfiler         bool
+	}{
+		{"", "", "debug", false},
+		{"production", "", "warn", false},
+		{"STAGING", ":6060", "info", true},
+	}
+
+	defer os.Unsetenv("APP_ENV")
+	defer os.Unsetenv("PROFILER_ADDR")
+	os.Unsetenv("LOG_LEVEL")
+	os.Unsetenv("PROFILER")
+	for _, tt := range tests {
+		os.Setenv("APP_ENV", tt.appEnv)
+		os.Setenv("PROFILER_ADDR", tt.profilerAddr)
+
+		var cfg Config
+		if err := env.NewParser().Unmarshal(&cfg); err != nil {
+			t.Fatalf("APP_ENV=%s: expected no error, got %v", tt.appEnv, err)
+		}
+		if cfg.LogLevel != tt.wantLevel || cfg.Profiler != tt.wantProfiler {
+			t.Errorf("APP_ENV=%s PROFILER_ADDR=%s: expected %s/%v, got %s/%v", tt.appEnv, tt.profilerAddr, tt.wantLevel, tt.wantProfiler, cfg.LogLevel, cfg.Profiler)
+		}
+	}
+}
+
+func TestKeepExisting(t *testing.T) {
+	type Config struct {
+		Host    string        `env:"name=HOST,default=localhost"`
+		Port    int           `env:"name=PORT,default=8080"`
+		Timeout time.Duration `env:"name=TIMEOUT,default=5s"`
+		Debug   bool          `env:"name=DEBUG,default=false,keep"`
+	}
+
+	os.Unsetenv("HOST")
+	os.Unsetenv("TIMEOUT")
+	os.Unsetenv("DEBUG")
+	os.Setenv("PORT", "9000")
+	defer os.Unsetenv("PORT")
+
+	cfg := Config{Host: "file.example.com", Port: 7000, Debug: true}
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "localhost" || !cfg.Debug {
+		t.Errorf("expected only the keep field to be kept, got %+v", cfg)
+	}
+
+	cfg = Config{Host: "file.example.com", Port: 7000}
+	var buf bytes.Buffer
+	if err := env.NewParser().WithKeepExisting(true).PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "file.example.com" {
+		t.Errorf("expected Host to be kept, got '%s'", cfg.Host)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("expected Port to be overridden by the variable, got %d", cfg.Port)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("expected Timeout to use the default, got %v", cfg.Timeout)
+	}
+	if !strings.Contains(buf.String(), "existing") {
+		t.Errorf("expected the table to report the existing source, got:\n%s", buf.String())
+	}
+}
+
+func TestExactNameMatch(t *testing.T) {
+	type Config struct {
+		Region string `env:"name=AWS_REGION,exact"`
+		Home   string `env:""`
+	}
+
+	os.Unsetenv("AWS_REGION")
+	os.Setenv("Region", "from-field-name")
+	defer os.Unsetenv("Region")
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", "/home/app")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Region != "" {
+		t.Errorf("expected Region to ignore the field name, got '%s'", cfg.Region)
+	}
+	if cfg.Home != "/home/app" {
+		t.Errorf("expected Home to match HOME without exact matching, got '%s'", cfg.Home)
+	}
+
+	cfg = Config{}
+	if err := env.NewParser().WithExactNameMatch().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Home != "" {
+		t.Errorf("expected Home to only match the field name, got '%s'", cfg.Home)
+	}
+}
+
+func TestSnakeCaseNames(t *testing.T) {
+	type Config struct {
+		MaxRetryCount  int    `env:""`
+		HTTPServerPort int    `env:""`
+		OAuth2Token    string `env:""`
+		DBHost         string `env:""`
+		Region         string `env:"name=AWS_REGION"`
+	}
+
+	vars := map[string]string{
+		"MAX_RETRY_COUNT":  "3",
+		"HTTP_SERVER_PORT": "8080",
+		"O_AUTH2_TOKEN":    "token",
+		"DB_HOST":          "db",
+		"REGION":           "eu-west-1",
+		"MAXRETRYCOUNT":    "9",
+	}
+	for k, v := range vars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	os.Unsetenv("AWS_REGION")
+
+	var cfg Config
+	if err := env.NewParser().WithSnakeCaseNames().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.MaxRetryCount != 3 || cfg.HTTPServerPort != 8080 || cfg.OAuth2Token != "token" || cfg.DBHost != "db" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("expected Region to fall back to REGION, got '%s'", cfg.Region)
+	}
+}
+
+func TestAutoPrefix(t *testing.T) {
+	type Pool struct {
+		MaxSize int `env:"default=1"`
+	}
+	type Database struct {
+		Host string `env:""`
+		Pool Pool
+	}
+	type Common struct {
+		Region string `env:""`
+	}
+	type Config struct {
+		Common
+		Database Database
+		Cache    Database `env:"prefix=REDIS_"`
+		Tracing  struct {
+			Endpoint string `env:"name=OTEL_ENDPOINT"`
+		} `env:"flatten"`
+	}
+
+	src := map[string]string{
+		"APP_REGION":                 "eu-west-1",
+		"APP_DATABASE_HOST":          "db",
+		"APP_DATABASE_POOL_MAX_SIZE": "10",
+		"APP_REDIS_HOST":             "cache",
+		"APP_OTEL_ENDPOINT":          "otel:4317",
+	}
+	var cfg Config
+	if err := env.NewParser().WithNamePrefix("APP_").WithSnakeCaseNames().WithAutoPrefix().UnmarshalFromMap(src, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Region != "eu-west-1" || cfg.Database.Host != "db" || cfg.Database.Pool.MaxSize != 10 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Cache.Host != "cache" || cfg.Cache.Pool.MaxSize != 1 {
+		t.Errorf("expected the prefix option to replace the field name, got %+v", cfg.Cache)
+	}
+	if cfg.Tracing.Endpoint != "otel:4317" {
+		t.Errorf("expected flatten to drop the field name, got '%s'", cfg.Tracing.Endpoint)
+	}
+
+	out, err := env.NewParser().WithSnakeCaseNames().WithAutoPrefix().Marshal(Config{Database: Database{Host: "db"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out["DATABASE_HOST"] != "db" {
+		t.Errorf("expected Marshal to use the automatic prefix, got %v", out)
+	}
+}
+
+func TestExplicitNamesOnly(t *testing.T) {
+	type Database struct {
+		Host string `env:"name=HOST"`
+		User string `env:"default=admin"`
+	}
+	type Config struct {
+		Path     string   `env:""`
+		Home     string   `env:"name=APP_HOME"`
+		Database Database `env:"prefix=DB_"`
+	}
+
+	vars := map[string]string{"Path": "/bin", "PATH_X": "x", "APP_HOME": "/app", "DB_HOST": "db", "DB_USER": "root"}
+	for k, v := range vars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	var cfg Config
+	if err := env.NewParser().WithExplicitNamesOnly().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Path != "" || cfg.Database.User != "" {
+		t.Errorf("expected fields without names to be unbound, got %+v", cfg)
+	}
+	if cfg.Home != "/app" || cfg.Database.Host != "db" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestStrictTags(t *testing.T) {
+	type Database struct {
+		Host    string `env:"name=DB_HOST,default=localhost"`
+		Timeout time.Duration
+	}
+	type Config struct {
+		Port     int    `env:"name=PORT,default=8080"`
+		Internal string `env:"-"`
+		Database Database
+		Debug    bool
+		secret   string
+	}
+
+	var cfg Config
+	err := env.NewParser().WithStrictTags().UnmarshalAll(&cfg)
+	if err == nil {
+		t.Fatal("expected an error for untagged fields")
+	}
+	for _, want := range []string{"Config.Database.Timeout: exported field has no env tag", "Config.Debug: exported field has no env tag"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Internal") || strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected ignored and unexported fields to pass, got %v", err)
+	}
+
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error without strict tags, got %v", err)
+	}
+	_ = cfg.secret
+}
+
+func TestValidateDryRun(t *testing.T) {
+	type Config struct {
+		Host     string `env:"name=HOST,required"`
+		Port     int    `env:"name=PORT,default=8080,min=1024"`
+		Password string `env:"name=PASSWORD,unset"`
+	}
+
+	os.Setenv("HOST", "example.com")
+	os.Setenv("PASSWORD", "secret")
+	defer os.Unsetenv("HOST")
+	defer os.Unsetenv("PASSWORD")
+
+	cfg := Config{Host: "existing"}
+	if err := env.NewParser().Validate(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "existing" || cfg.Port != 0 || cfg.Password != "" {
+		t.Errorf("expected the struct to be unchanged, got %+v", cfg)
+	}
+	if _, ok := os.LookupEnv("PASSWORD"); !ok {
+		t.Errorf("expected PASSWORD to remain set")
+	}
+
+	os.Unsetenv("HOST")
+	os.Setenv("PORT", "80")
+	defer os.Unsetenv("PORT")
+	err := env.NewParser().Validate(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Config.Host") || !strings.Contains(err.Error(), "Config.Port (PORT)") {
+		t.Errorf("expected errors for all invalid fields, got %v", err)
+	}
+
+	// Existing values of `keep` fields satisfy their requirements, as with Unmarshal
+	type Kept struct {
+		Region string `env:"name=REGION,required,keep"`
+	}
+	kept := Kept{Region: "eu-west-1"}
+	if err := env.NewParser().Validate(&kept); err != nil {
+		t.Errorf("expected the kept value to be validated, got %v", err)
+	}
+	if err := env.NewParser().Validate(&Kept{}); err == nil {
+		t.Errorf("expected an error for a missing kept value, got none")
+	}
+}
+
+func TestValidateLeavesJSONMapUnchanged(t *testing.T) {
+	type Config struct {
+		Limits map[string]int `env:"name=LIMITS,json"`
+	}
+
+	os.Setenv("LIMITS", `{"b":2}`)
+	defer os.Unsetenv("LIMITS")
+
+	cfg := Config{Limits: map[string]int{"a": 1}}
+	if err := env.NewParser().Validate(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.Limits) != 1 || cfg.Limits["a"] != 1 {
+		t.Errorf("expected the map to be unchanged, got %v", cfg.Limits)
+	}
+}
+
+func TestReload(t *testing.T) {
+	type Database struct {
+		Host string `env:"name=DB_HOST,default=localhost"`
+		Port int    `env:"name=DB_PORT,default=5432"`
+	}
+	type Config struct {
+		Level    string   `env:"name=LOG_LEVEL,default=info"`
+		Hosts    []string `env:"name=HOSTS"`
+		Database Database
+		Version  string
+	}
+
+	os.Setenv("HOSTS", "a|b")
+	defer os.Unsetenv("HOSTS")
+	defer os.Unsetenv("LOG_LEVEL")
+	defer os.Unsetenv("DB_PORT")
+
+	parser := env.NewParser()
+	cfg := Config{Version: "1.0"}
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	changed, err := parser.Reload(&cfg)
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("expected no changes, got %v, %v", changed, err)
+	}
+
+	os.Setenv("LOG_LEVEL", "debug")
+	os.Setenv("DB_PORT", "6543")
+	changed, err = parser.Reload(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Join(changed, ",") != "Level,Database.Port" {
+		t.Errorf("expected Level and Database.Port to change, got %v", changed)
+	}
+	if cfg.Level != "debug" || cfg.Database.Port != 6543 || cfg.Version != "1.0" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	os.Setenv("DB_PORT", "invalid")
+	if _, err := parser.Reload(&cfg); err == nil {
+		t.Fatal("expected an error for an invalid port")
+	}
+	if cfg.Database.Port != 6543 {
+		t.Errorf("expected the config to be unchanged after a failed reload, got %+v", cfg)
+	}
+}
+
+func TestReloadJSONMap(t *testing.T) {
+	type Config struct {
+		Limits map[string]int `env:"name=LIMITS,json"`
+		Port   int            `env:"name=PORT,default=8080"`
+	}
+
+	os.Setenv("LIMITS", `{"a":1}`)
+	defer os.Unsetenv("LIMITS")
+	defer os.Unsetenv("PORT")
+
+	parser := env.NewParser()
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("LIMITS", `{"b":2}`)
+	os.Setenv("PORT", "invalid")
+	if _, err := parser.Reload(&cfg); err == nil {
+		t.Fatal("expected an error for an invalid port")
+	}
+	if len(cfg.Limits) != 1 || cfg.Limits["a"] != 1 {
+		t.Errorf("expected the map to be unchanged after a failed reload, got %v", cfg.Limits)
+	}
+
+	os.Unsetenv("PORT")
+	changed, err := parser.Reload(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Join(changed, ",") != "Limits" {
+		t.Errorf("expected Limits to change, got %v", changed)
+	}
+	if len(cfg.Limits) != 1 || cfg.Limits["b"] != 2 {
+		t.Errorf("expected the map to be replaced without stale keys, got %v", cfg.Limits)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	type Config struct {
+		Mode  string `env:"name=MODE|APP_MODE,lower"`
+		Port  int    `env:"name=PORT,default=8080"`
+		Token string `env:"name=TOKEN,sensitive"`
+		Level int    `env:"name=LEVEL,max=5"`
+	}
+
+	os.Unsetenv("MODE")
+	os.Unsetenv("PORT")
+	os.Setenv("APP_MODE", " FAST ")
+	os.Setenv("TOKEN", "s3cret")
+	os.Setenv("LEVEL", "9")
+	defer os.Unsetenv("APP_MODE")
+	defer os.Unsetenv("TOKEN")
+	defer os.Unsetenv("LEVEL")
+
+	var buf bytes.Buffer
+	var cfg Config
+	err := env.NewParser().WithTrace(&buf).Unmarshal(&cfg)
+	if err == nil {
+		t.Fatal("expected an error for LEVEL")
+	}
+
+	expected := `Config.Mode: candidates MODE, APP_MODE, Mode, mode; matched APP_MODE
+Config.Mode: trimmed whitespace
+Config.Mode: converted to lower case
+Config.Mode: value "fast" from env
+Config.Mode: set
+Config.Port: candidates PORT, Port, port; none set
+Config.Port: using the default
+Config.Port: value "8080" from default
+Config.Port: set
+Config.Token: candidates TOKEN, Token, token; matched TOKEN
+Config.Token: value *** from env
+Config.Token: set
+Config.Level: candidates LEVEL, Level, level; matched LEVEL
+Config.Level: value "9" from env
+Config.Level: failed: Config.Level (LEVEL): value 9 is greater than maximum allowed 5
+`
+	if buf.String() != expected {
+		t.Errorf("expected trace:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestLogger(t *testing.T) {
+	type Config struct {
+		Host  string `env:"name=HOST"`
+		Port  int    `env:"name=PORT,default=8080"`
+		Level int    `env:"name=LEVEL,max=5"`
+	}
+
+	os.Setenv("HOST", "localhost")
+	os.Unsetenv("PORT")
+	defer os.Unsetenv("HOST")
+	defer os.Unsetenv("LEVEL")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	var cfg Config
+	os.Setenv("LEVEL", "3")
+	if err := env.NewParser().WithLogger(logger).Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := `level=DEBUG msg="env field resolved" struct=Config field=Host env=HOST source=env
+level=DEBUG msg="env field resolved" struct=Config field=Port source=default
+level=DEBUG msg="env field resolved" struct=Config field=Level env=LEVEL source=env
+level=INFO msg="env config loaded" struct=Config fields=3
+`
+	if buf.String() != expected {
+		t.Errorf("expected log:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	os.Setenv("LEVEL", "9")
+	if err := env.NewParser().WithLogger(logger).Unmarshal(&cfg); err == nil {
+		t.Fatal("expected an error for LEVEL")
+	}
+	if !strings.Contains(buf.String(), `level=ERROR msg="env field failed" struct=Config field=Level error="Config.Level (LEVEL): value 9 is greater than maximum allowed 5"`) ||
+		!strings.Contains(buf.String(), `level=ERROR msg="env config failed" struct=Config fields=3`) {
+		t.Errorf("expected error events, got:\n%s", buf.String())
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	type Database struct {
+		Host string `env:"name=DB_HOST,default=localhost"`
+		Port int    `env:"name=DB_PORT,default=5432,min=1"`
+	}
+	type Config struct {
+		Name     string        `env:"name=APP_NAME,required"`
+		Timeout  time.Duration `env:"name=TIMEOUT,default=5s"`
+		Hosts    []string      `env:"name=HOSTS,default=a|b"`
+		Count    int           `env:"name=COUNT"`
+		Database Database
+	}
+
+	os.Setenv("DB_HOST", "from-env")
+	os.Setenv("COUNT", "3")
+	defer os.Unsetenv("DB_HOST")
+	defer os.Unsetenv("COUNT")
+
+	var cfg Config
+	if err := env.NewParser().ApplyDefaults(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Timeout != 5*time.Second || strings.Join(cfg.Hosts, ",") != "a,b" || cfg.Database.Host != "localhost" || cfg.Database.Port != 5432 {
+		t.Errorf("expected defaults to be applied, got %+v", cfg)
+	}
+	if cfg.Name != "" || cfg.Count != 0 {
+		t.Errorf("expected the environment to be ignored, got %+v", cfg)
+	}
+
+	type Invalid struct {
+		Port int `env:"name=PORT,default=0,min=1"`
+	}
+	if err := env.NewParser().ApplyDefaults(&Invalid{}); err == nil {
+		t.Errorf("expected an error for an invalid default")
+	}
+}
+
+func TestFileVariants(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,required"`
+		User     string `env:"name=DB_USER"`
+		Token    string `env:"name=TOKEN,default=none"`
+	}
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db_password")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("APP_DB_PASSWORD_FILE", secret)
+	os.Setenv("APP_DB_USER", "admin")
+	os.Setenv("APP_DB_USER_FILE", secret)
+	defer os.Unsetenv("APP_DB_PASSWORD_FILE")
+	defer os.Unsetenv("APP_DB_USER")
+	defer os.Unsetenv("APP_DB_USER_FILE")
+
+	var buf bytes.Buffer
+	var cfg Config
+	parser := env.NewParser().WithNamePrefix("APP_").WithFileVariants(true).WithStrictUnknown()
+	if err := parser.PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.User != "admin" || cfg.Token != "none" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if !strings.Contains(buf.String(), "APP_DB_PASSWORD_FILE  ***") {
+		t.Errorf("expected the value from the file to be masked, got:\n%s", buf.String())
+	}
+
+	os.Setenv("APP_DB_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	err := parser.Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Config.Password (APP_DB_PASSWORD_FILE): reading value from file") {
+		t.Errorf("expected a file error, got %v", err)
+	}
+
+	os.Unsetenv("APP_DB_PASSWORD_FILE")
+	os.Setenv("APP_DB_PASSWORD", "plain")
+	defer os.Unsetenv("APP_DB_PASSWORD")
+	if err := env.NewParser().WithNamePrefix("APP_").Unmarshal(&cfg); err != nil || cfg.Password != "plain" {
+		t.Errorf("expected the plain variable, got %v, %+v", err, cfg)
+	}
+}
+
+func TestDecryptor(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,encrypted"`
+		Token    string `env:"name=TOKEN"`
+		Host     string `env:"name=HOST"`
+	}
+
+	// reverse stands in for a KMS decryption
+	reverse := func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+		if string(ciphertext) == "bad" {
+			return nil, errors.New("InvalidCiphertextException")
+		}
+		out := make([]byte, len(ciphertext))
+		for i, b := range ciphertext {
+			out[len(ciphertext)-1-i] = b
+		}
+		return out, nil
+	}
+	encrypt := func(s string) string {
+		b, _ := reverse(context.Background(), []byte(s))
+		return "enc:" + base64.StdEncoding.EncodeToString(b)
+	}
+
+	vars := map[string]string{"DB_PASSWORD": encrypt("s3cret"), "TOKEN": encrypt("token"), "HOST": "localhost"}
+	for k, v := range vars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	var buf bytes.Buffer
+	var cfg Config
+	parser := env.NewParser().WithDecryptor(reverse)
+	if err := parser.PrintTable(&buf, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Token != "token" || cfg.Host != "localhost" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if strings.Contains(buf.String(), "s3cret") || strings.Contains(buf.String(), "token") {
+		t.Errorf("expected decrypted values to be masked, got:\n%s", buf.String())
+	}
+
+	tests := map[string]string{
+		"plaintext": "Config.Password (DB_PASSWORD): value must be encrypted (enc:...)",
+		"enc:%%%":   "Config.Password (DB_PASSWORD): encrypted value is not valid base64",
+		"enc:" + base64.StdEncoding.EncodeToString([]byte("bad")): "Config.Password (DB_PASSWORD): decrypting value: InvalidCiphertextException",
+	}
+	for val, want := range tests {
+		os.Setenv("DB_PASSWORD", val)
+		if err := parser.Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", val, want, err)
+		}
+	}
+
+	os.Setenv("DB_PASSWORD", encrypt("s3cret"))
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "encrypted value but no decryptor is configured") {
+		t.Errorf("expected a missing decryptor error, got %v", err)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	type Database struct {
+		Host string `env:"name=DB_HOST,default=localhost"`
+		Port int    `env:"name=DB_PORT,default=5432"`
+		Name string `env:"name=DB_NAME,default=app"`
+	}
+	type Config struct {
+		URL      string        `env:"name=DB_URL,default=postgres://{{.Database.Host}}:{{.Database.Port}}/{{.DBName}}"`
+		DBName   string        `env:"name=DB_DATABASE,default={{.Database.Name}}_{{.Env}}"`
+		Env      string        `env:"name=ENV,default=dev"`
+		Timeout  time.Duration `env:"name=TIMEOUT,default=5s"`
+		Template string        `env:"name=TEMPLATE,default=Hello {{.Name}}"`
+		Label    string        `env:"name=LABEL,interpolate,default=timeout {{.Timeout}}"`
+		Database Database
+	}
+
+	os.Setenv("DB_HOST", "db")
+	os.Setenv("ENV", "prod")
+	defer os.Unsetenv("DB_HOST")
+	defer os.Unsetenv("ENV")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.URL != "postgres://{{.Database.Host}}:{{.Database.Port}}/{{.DBName}}" || cfg.Label != "timeout 5s" {
+		t.Errorf("expected only fields with the interpolate option to be interpolated by default, got %+v", cfg)
+	}
+
+	cfg = Config{}
+	parser := env.NewParser().WithInterpolation(true)
+	if err := parser.Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), "Config.Template: unknown field Name referenced") {
+		t.Fatalf("expected an unknown field error, got %v", err)
+	}
+
+	os.Setenv("TEMPLATE", "static")
+	defer os.Unsetenv("TEMPLATE")
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.URL != "postgres://db:5432/app_prod" || cfg.DBName != "app_prod" {
+		t.Errorf("expected references to be substituted in dependency order, got %+v", cfg)
+	}
+
+	type Cycle struct {
+		A string `env:"name=A,default={{.B}}"`
+		B string `env:"name=B,default=x{{.A}}"`
+		C string `env:"name=C,default={{.A}}"`
+	}
+	err := parser.UnmarshalAll(&Cycle{})
+	if err == nil || !strings.Contains(err.Error(), "field reference cycle: Cycle.A -> Cycle.B -> Cycle.A") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "Cycle.C: referenced field A is invalid") {
+		t.Errorf("expected an error for the field referencing an invalid one, got %v", err)
+	}
+}
+
+func TestAllRequired(t *testing.T) {
+	type Config struct {
+		Host    string `env:"name=HOST"`
+		Port    int    `env:"name=PORT,default=8080"`
+		Debug   bool   `env:"name=DEBUG,optional"`
+		Token   string `env:"name=TOKEN"`
+		Ignored string
+	}
+
+	os.Setenv("HOST", "localhost")
+	os.Setenv("DEBUG", "false")
+	os.Unsetenv("TOKEN")
+	os.Unsetenv("PORT")
+	defer os.Unsetenv("HOST")
+	defer os.Unsetenv("DEBUG")
+
+	var cfg Config
+	parser := env.NewParser().WithAllRequired()
+	err := parser.UnmarshalAll(&cfg)
+	var reqErr *env.RequiredError
+	if !errors.As(err, &reqErr) || reqErr.Field != "Token" {
+		t.Fatalf("expected a required error for Token, got %v", err)
+	}
+	if strings.Contains(err.Error(), "Config.Host") || strings.Contains(err.Error(), "Config.Port") || strings.Contains(err.Error(), "Config.Debug") {
+		t.Errorf("expected set, defaulted and optional fields to pass, got %v", err)
+	}
+
+	docs, err := parser.Document(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, d := range docs {
+		if want := d.Field == "Host" || d.Field == "Token"; d.Required != want {
+			t.Errorf("expected %s to be documented with Required=%v", d.Field, want)
+		}
+	}
+
+	os.Setenv("TOKEN", "token")
+	defer os.Unsetenv("TOKEN")
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCaarlosTags(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT" envDefault:"5432"`
+	}
+	type Config struct {
+		Name    string        `env:"NAME,notEmpty"`
+		Hosts   []string      `env:"HOSTS"`
+		Ports   []int         `env:"PORTS" envSeparator:":"`
+		Timeout time.Duration `env:"TIMEOUT" envDefault:"5s"`
+		DB      Database      `envPrefix:"DB_"`
+		Retries int           `env:"RETRIES"`
+		Skipped string        `env:"-"`
+		Unbound string        `env:",required"`
+	}
+
+	os.Setenv("APP_NAME", "svc")
+	os.Setenv("APP_HOSTS", "a, b")
+	os.Setenv("APP_PORTS", "80:443")
+	os.Setenv("APP_DB_HOST", "db")
+	os.Setenv("APP_UNBOUND", "x")
+	defer os.Unsetenv("APP_NAME")
+	defer os.Unsetenv("APP_HOSTS")
+	defer os.Unsetenv("APP_PORTS")
+	defer os.Unsetenv("APP_DB_HOST")
+	defer os.Unsetenv("APP_UNBOUND")
+
+	cfg := Config{Retries: 3}
+	parser := env.NewParser().WithNamePrefix("APP_").WithCaarlosTags(true)
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Name != "svc" || !slices.Equal(cfg.Hosts, []string{"a", "b"}) || !slices.Equal(cfg.Ports, []int{80, 443}) {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+	if cfg.Timeout != 5*time.Second || cfg.DB.Host != "db" || cfg.DB.Port != 5432 {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+	if cfg.Unbound != "" {
+		t.Errorf("expected fields without a name to be unbound, got %q", cfg.Unbound)
+	}
+	if cfg.Retries != 3 {
+		t.Errorf("expected the unset field to be left unchanged, got %d", cfg.Retries)
+	}
+
+	os.Unsetenv("APP_NAME")
+	err := parser.Unmarshal(&Config{})
+	var reqErr *env.RequiredError
+	if !errors.As(err, &reqErr) || reqErr.Field != "Name" {
+		t.Errorf("expected a required error for Name, got %v", err)
+	}
+}
+
+func TestSliceSeparatorOption(t *testing.T) {
+	type Config struct {
+		Paths []string `env:"name=PATHS,separator=:"`
+	}
+
+	os.Setenv("PATHS", "/usr/bin:/bin")
+	defer os.Unsetenv("PATHS")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(cfg.Paths, []string{"/usr/bin", "/bin"}) {
+		t.Errorf("expected the paths to be split at colons, got %v", cfg.Paths)
+	}
+
+	vars, err := env.NewParser().Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if vars["PATHS"] != "/usr/bin:/bin" {
+		t.Errorf("expected the paths to be joined with colons, got %q", vars["PATHS"])
+	}
+}
+
+func TestStrictDuplicates(t *testing.T) {
+	type Database struct {
+		Host string `env:"name=HOST"`
+		Port int    `env:"name=PORT,default=5432"`
+	}
+	type Config struct {
+		Primary Database `env:"prefix=PRIMARY_"`
+		Replica Database `env:"prefix=PRIMARY_"`
+		Port    int      `env:"name=API_PORT,default=8080"`
+		Addr    string   `env:"name=API_ADDR|API_PORT"`
+	}
+
+	os.Setenv("APP_PRIMARY_HOST", "db")
+	defer os.Unsetenv("APP_PRIMARY_HOST")
+
+	parser := env.NewParser().WithNamePrefix("APP_")
+	if err := parser.Unmarshal(&Config{}); err != nil {
+		t.Fatalf("expected no error without the check, got %v", err)
+	}
+
+	err := parser.WithStrictDuplicates().Unmarshal(&Config{})
+	for _, want := range []string{
+		"Config.Replica.Host: variable APP_PRIMARY_HOST is already read by Config.Primary.Host",
+		"Config.Replica.Port: variable APP_PRIMARY_PORT is already read by Config.Primary.Port",
+		"Config.Addr: variable APP_API_PORT is already read by Config.Port",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+
+	type Distinct struct {
+		Host string `env:"name=API_HOST"`
+		DB   struct {
+			Host string `env:"name=HOST"`
+		} `env:"prefix=DB_"`
+	}
+	if err := env.NewParser().WithStrictDuplicates().Unmarshal(&Distinct{}); err != nil {
+		t.Errorf("expected no error for distinct names, got %v", err)
+	}
+}
+
+func TestDatetimeValidation(t *testing.T) {
+	type Config struct {
+		ReleaseDate string `env:"name=RELEASE_DATE,v_datetime=2006-01-02"`
+	}
+
+	os.Setenv("RELEASE_DATE", "2024-02-29")
+	defer os.Unsetenv("RELEASE_DATE")
+
+	var cfg Config
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.ReleaseDate != "2024-02-29" {
+		t.Errorf("expected ReleaseDate to be '2024-02-29', got '%s'", cfg.ReleaseDate)
+	}
+
+	os.Setenv("RELEASE_DATE", "2023-02-29")
+	err := env.NewParser().Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "invalid date/time: 2023-02-29") {
+		t.Errorf("expected a date/time validation error, got %v", err)
+	}
+}
+
+func TestJSONAndBase64Validation(t *testing.T) {
+	type Config struct {
+		Policy string `env:"name=POLICY,v_json"`
+		Key    string `env:"name=KEY,v_base64"`
+	}
+
+	tests := []struct {
+		policy, key string
+		wantErr     bool
+	}{
+		{`{"Version":"2012-10-17"}`, "c2VjcmV0", false},
+		{`[1,2,3]`, "c2VjcmV0LWtleQ", false},
+		{`{"Version":`, "c2VjcmV0", true},
+		{`{}`, "not base64!", true},
+	}
+
+	defer os.Unsetenv("POLICY")
+	defer os.Unsetenv("KEY")
+	for _, tt := range tests {
+		os.Setenv("POLICY", tt.policy)
+		os.Setenv("KEY", tt.key)
+
+		var cfg Config
+		err := env.NewParser().Unmarshal(&cfg)
+		if tt.wantErr && err == nil {
+			t.Errorf("POLICY=%s KEY=%s: expected an error, got none", tt.policy, tt.key)
+		}
+		if !tt.wantErr && (err != nil || cfg.Policy != tt.policy || cfg.Key != tt.key) {
+			t.Errorf("POLICY=%s KEY=%s: expected the values to be kept, got %+v, %v", tt.policy, tt.key, cfg, err)
+		}
+	}
+}
+
+func TestUnmarshalAll(t *testing.T) {
+	type Config struct {
+		Host     string `env:"name=HOST,required"`
+		Port     int    `env:"name=PORT,min=1024"`
+		Database struct {
+			Name string `env:"name=DB_NAME,required"`
+		}
+		Region string `env:"name=REGION,default=us-east-1"`
+	}
+
+	os.Unsetenv("HOST")
+	os.Unsetenv("DB_NAME")
+	os.Unsetenv("REGION")
+	os.Setenv("PORT", "80")
+	defer os.Unsetenv("PORT")
+
+	var cfg Config
+	err := env.NewParser().UnmarshalAll(&cfg)
+	if err == nil {
+		t.Fatalf("expected errors, got none")
+	}
+	for _, want := range []string{"HOST", "value 80 is less than minimum allowed 1024", "DB_NAME"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the errors to mention %q, got %v", want, err)
+		}
+	}
+	if cfg.Region != "us-east-1" {
+		t.Errorf("expected valid fields to be populated, got Region '%s'", cfg.Region)
+	}
+
+	err = env.NewParser().Unmarshal(&cfg)
+	if err == nil || strings.Contains(err.Error(), "DB_NAME") {
+		t.Errorf("expected Unmarshal to stop at the first error, got %v", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	type Config struct {
+		Database struct {
+			Host     string `env:"name=DB_HOST,required"`
+			Port     int    `env:"name=DB_PORT,min=1024"`
+			Timeout  int    `env:"name=DB_TIMEOUT"`
+			Password string `env:"name=DB_PASSWORD,sensitive,len=12"`
+		}
+	}
+
+	os.Unsetenv("DB_HOST")
+	os.Setenv("DB_PORT", "80")
+	os.Setenv("DB_TIMEOUT", "soon")
+	os.Setenv("DB_PASSWORD", "hunter2")
+	defer os.Unsetenv("DB_PORT")
+	defer os.Unsetenv("DB_TIMEOUT")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	var cfg Config
+	err := env.NewParser().UnmarshalAll(&cfg)
+	if err == nil {
+		t.Fatalf("expected errors, got none")
+	}
+
+	var reqErr *env.RequiredError
+	if !errors.As(err, &reqErr) || reqErr.Field != "Database.Host" || reqErr.Names[0] != "DB_HOST" {
+		t.Errorf("expected a RequiredError for Database.Host, got %#v", reqErr)
+	}
+
+	var parseErr *env.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Field != "Database.Timeout" || parseErr.Value != "soon" {
+		t.Errorf("expected a ParseError for Database.Timeout, got %#v", parseErr)
+	}
+
+	var valErrs []*env.ValidationError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var ve *env.ValidationError
+		if errors.As(e, &ve) {
+			valErrs = append(valErrs, ve)
+		}
+	}
+	if len(valErrs) != 2 {
+		t.Fatalf("expected 2 validation errors, got %d", len(valErrs))
+	}
+	if valErrs[0].Field != "Database.Port" || valErrs[0].Value != "80" {
+		t.Errorf("unexpected validation error for the port: %#v", valErrs[0])
+	}
+	if valErrs[1].Field != "Database.Password" || valErrs[1].Value != "***" || strings.Contains(valErrs[1].Error(), "hunter2") {
+		t.Errorf("expected the password to be redacted, got %#v (%v)", valErrs[1], valErrs[1])
+	}
+}
+
+func TestErrorMessagesIncludeFieldPath(t *testing.T) {
+	type Database struct {
+		Port int `env:"name=DB_PORT,min=8000"`
+	}
+	type Config struct {
+		Database Database
+	}
+
+	os.Setenv("DB_PORT", "7000")
+	defer os.Unsetenv("DB_PORT")
+
+	var cfg Config
+	err := env.NewParser().Unmarshal(&cfg)
+	want := "Config.Database.Port (DB_PORT): value 7000 is less than minimum allowed 8000"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got %v", want, err)
+	}
+}
+
+func TestWithLookup(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=LOOKUP_HOST"`
+		URL  string `env:"name=LOOKUP_URL,expand"`
+		Key  string `env:"name=LOOKUP_KEY,required_if=LOOKUP_HOST"`
+	}
+
+	os.Setenv("LOOKUP_HOST", "from-env")
+	defer os.Unsetenv("LOOKUP_HOST")
+
+	vals := map[string]string{"LOOKUP_HOST": "example.com", "LOOKUP_URL": "https://${LOOKUP_HOST}/api"}
+	parser := env.NewParser().WithLookup(func(key string) (string, bool) {
+		v, ok := vals[key]
+		return v, ok
+	})
+
+	var cfg Config
+	err := parser.Unmarshal(&cfg)
+	var reqErr *env.RequiredError
+	if !errors.As(err, &reqErr) || reqErr.Field != "Key" {
+		t.Fatalf("expected a RequiredError for Key, got %v", err)
+	}
+
+	vals["LOOKUP_KEY"] = "secret"
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "example.com" || cfg.URL != "https://example.com/api" || cfg.Key != "secret" {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+}
+
+func TestUnmarshalFromMap(t *testing.T) {
+	type Config struct {
+		Host  string   `env:"name=MAP_HOST,required"`
+		Port  int      `env:"name=MAP_PORT,default=8080"`
+		Tags  []string `env:"name=MAP_TAGS"`
+		Token string   `env:"name=MAP_TOKEN,unset"`
+	}
+
+	os.Setenv("MAP_HOST", "from-env")
+	os.Setenv("MAP_TOKEN", "env-token")
+	defer os.Unsetenv("MAP_HOST")
+	defer os.Unsetenv("MAP_TOKEN")
+
+	var cfg Config
+	src := map[string]string{"MAP_HOST": "example.com", "MAP_TAGS": "a|b", "MAP_TOKEN": "map-token"}
+	if err := env.NewParser().UnmarshalFromMap(src, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "example.com" || cfg.Port != 8080 || strings.Join(cfg.Tags, ",") != "a,b" || cfg.Token != "map-token" {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+	if os.Getenv("MAP_TOKEN") != "env-token" {
+		t.Errorf("expected the process environment to be left untouched")
+	}
+
+	err := env.NewParser().UnmarshalFromMap(map[string]string{}, &cfg)
+	var reqErr *env.RequiredError
+	if !errors.As(err, &reqErr) || reqErr.Field != "Host" {
+		t.Errorf("expected a RequiredError for Host, got %v", err)
+	}
+}
+
+func TestUnmarshalFromEnviron(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=ENVIRON_HOST"`
+		DSN  string `env:"name=ENVIRON_DSN"`
+		Port int    `env:"name=ENVIRON_PORT,default=80"`
+	}
+
+	environ := []string{"ENVIRON_HOST=first", "ENVIRON_DSN=user=app dbname=main", "INVALID", "ENVIRON_HOST=last"}
+	var cfg Config
+	if err := env.NewParser().UnmarshalFromEnviron(environ, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "last" || cfg.DSN != "user=app dbname=main" || cfg.Port != 80 {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+}
+
+func TestUnmarshalWithOverrides(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=OVR_HOST"`
+		DB   struct {
+			Port int `env:"name=PORT|LEGACY_PORT,default=5432"`
+		} `env:"prefix=OVR_DB_"`
+		Debug bool `env:"name=OVR_DEBUG,default=false"`
+	}
+
+	os.Setenv("OVR_HOST", "env")
+	os.Setenv("OVR_DB_LEGACY_PORT", "1111")
+	defer os.Unsetenv("OVR_HOST")
+	defer os.Unsetenv("OVR_DB_LEGACY_PORT")
+
+	var cfg Config
+	parser := env.NewParser().WithResolvePolicy(env.ErrorOnConflict)
+	err := parser.UnmarshalWithOverrides(&cfg, map[string]string{"DB.Port": "6543", "OVR_DEBUG": "true"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "env" || cfg.DB.Port != 6543 || !cfg.Debug {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+	if _, ok := os.LookupEnv("OVR_DEBUG"); ok {
+		t.Errorf("expected the environment to be unchanged")
+	}
+}
+
+func TestStrictUnknown(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `env:"name=TIMEOUT,default=5s"`
+		DB      struct {
+			Host string `env:"name=HOST"`
+		} `env:"prefix=DB_"`
+	}
+
+	os.Setenv("STRICT_TIMEOUTT", "10s")
+	os.Setenv("STRICT_DB_HOST", "db")
+	os.Setenv("STRICT_DB_PORT", "5432")
+	os.Setenv("OTHER_VALUE", "ignored")
+	defer os.Unsetenv("STRICT_TIMEOUTT")
+	defer os.Unsetenv("STRICT_DB_HOST")
+	defer os.Unsetenv("STRICT_DB_PORT")
+	defer os.Unsetenv("OTHER_VALUE")
+
+	var cfg Config
+	if err := env.NewParser().WithNamePrefix("STRICT_").Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error without strict mode, got %v", err)
+	}
+
+	err := env.NewParser().WithNamePrefix("STRICT_").WithStrictUnknown().Unmarshal(&cfg)
+	want := "unknown environment variables with prefix STRICT_: STRICT_DB_PORT, STRICT_TIMEOUTT"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got %v", want, err)
+	}
+
+	src := map[string]string{"STRICT_TIMEOUT": "1s", "STRICT_DB_HOST": "db"}
+	if err := env.NewParser().WithNamePrefix("STRICT_").WithStrictUnknown().UnmarshalFromMap(src, &cfg); err != nil {
+		t.Errorf("expected no error for a map without unknown variables, got %v", err)
+	}
+}
+
+func TestStrictUnknownReferences(t *testing.T) {
+	type Config struct {
+		Port     int    `env:"name=PORT,defaultenv=STRICTREF_LEGACY_PORT"`
+		LogDir   string `env:"name=LOG_DIR,expand"`
+		Password string `env:"name=PASSWORD,required_if=USER"`
+	}
+
+	src := map[string]string{
+		"STRICTREF_LEGACY_PORT": "8080",
+		"STRICTREF_LOG_DIR":     "${STRICTREF_HOME}/log",
+		"STRICTREF_HOME":        "/srv",
+		"STRICTREF_USER":        "admin",
+		"STRICTREF_PASSWORD":    "secret",
+	}
+	var cfg Config
+	parser := env.NewParser().WithNamePrefix("STRICTREF_").WithStrictUnknown()
+	if err := parser.UnmarshalFromMap(src, &cfg); err != nil {
+		t.Fatalf("expected referenced variables to count as read, got %v", err)
+	}
+	if cfg.Port != 8080 || cfg.LogDir != "/srv/log" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	lookup := func(key string) (string, bool) {
+		val, ok := src[key]
+		return val, ok
+	}
+	err := env.NewParser().WithNamePrefix("STRICTREF_").WithStrictUnknown().WithLookup(lookup).Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "custom lookup function") {
+		t.Errorf("expected an error for a custom lookup function, got %v", err)
+	}
+}
+
+func TestParseGeneric(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=HOST,required"`
+		Port int    `env:"name=PORT,default=8080"`
+	}
+
+	os.Setenv("GENERIC_HOST", "example.com")
+	defer os.Unsetenv("GENERIC_HOST")
+
+	cfg, err := env.Parse[Config](func(p *env.Parser) { p.WithNamePrefix("GENERIC_") })
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "example.com" || cfg.Port != 8080 {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+
+	if _, err := env.Parse[Config](); err == nil {
+		t.Errorf("expected an error for the missing HOST")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected MustParse to panic")
+		}
+	}()
+	env.MustParse[Config]()
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=CTX_HOST,default=localhost"`
+	}
+
+	var cfg Config
+	if err := env.NewParser().UnmarshalContext(context.Background(), &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("expected localhost, got %s", cfg.Host)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var canceled Config
+	err := env.NewParser().UnmarshalContext(ctx, &canceled)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if canceled.Host != "" {
+		t.Errorf("expected no field to be populated, got %+v", canceled)
+	}
+}
+
+func TestUnmarshalContextCancelsExec(t *testing.T) {
+	type Config struct {
+		Value string `env:"name=CTX_EXEC"`
+	}
+
+	os.Setenv("CTX_EXEC", "exec:/bin/sleep 5")
+	defer os.Unsetenv("CTX_EXEC")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var cfg Config
+	err := env.NewParser().WithExecAllowlist("/bin/sleep").UnmarshalContext(ctx, &cfg)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestFieldHooks(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=HOOK_PASSWORD,required"`
+		Port     int    `env:"name=HOOK_PORT,default=8080,max=9000"`
+	}
+
+	os.Setenv("HOOK_PASSWORD", "secret://s3cret")
+	defer os.Unsetenv("HOOK_PASSWORD")
+
+	var set []string
+	parser := env.NewParser().
+		WithFieldHook(func(f env.FieldInfo, raw string) (string, error) {
+			if f.Path == "Password" && f.Var != "HOOK_PASSWORD" {
+				t.Errorf("expected the variable name in the field info, got %+v", f)
+			}
+			return strings.TrimPrefix(raw, "secret://"), nil
+		}).
+		WithPostSetHook(func(f env.FieldInfo, value interface{}) error {
+			set = append(set, fmt.Sprintf("%s=%v (%s)", f.Path, value, f.Source))
+			return nil
+		})
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Port != 8080 {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+	if strings.Join(set, ", ") != "Password=s3cret (env), Port=8080 (default)" {
+		t.Errorf("unexpected post-set calls: %v", set)
+	}
+
+	// Values returned by the field hook are validated like other values
+	parser.WithFieldHook(func(f env.FieldInfo, raw string) (string, error) {
+		if f.Path == "Port" {
+			return "9999", nil
+		}
+		return raw, nil
+	})
+	var ve *env.ValidationError
+	if err := parser.Unmarshal(&cfg); !errors.As(err, &ve) || ve.Field != "Port" {
+		t.Errorf("expected a ValidationError for Port, got %v", err)
+	}
+
+	parser.WithFieldHook(nil).WithPostSetHook(func(f env.FieldInfo, value interface{}) error {
+		return errors.New("rejected")
+	})
+	if err := parser.Unmarshal(&cfg); err == nil || err.Error() != "Config.Password (HOOK_PASSWORD): rejected" {
+		t.Errorf("expected the post-set hook error, got %v", err)
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	type Config struct {
+		Port  int   `env:"name=CUSTOM_PORT,v_port_range"`
+		Ports []int `env:"name=CUSTOM_PORTS,V_PORT_RANGE"`
+	}
+
+	portRange := func(val string) error {
+		if n, err := strconv.Atoi(val); err != nil || n < 1024 || n > 49151 {
+			return fmt.Errorf("invalid value: %s. Must be a registered port (1024-49151)", val)
+		}
+		return nil
+	}
+	parser := env.NewParser().RegisterValidator("v_port_range", portRange)
+
+	os.Setenv("CUSTOM_PORT", "8080")
+	os.Setenv("CUSTOM_PORTS", "8080|9090")
+	defer os.Unsetenv("CUSTOM_PORT")
+	defer os.Unsetenv("CUSTOM_PORTS")
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Port != 8080 || len(cfg.Ports) != 2 {
+		t.Errorf("unexpected values: %+v", cfg)
+	}
+
+	os.Setenv("CUSTOM_PORTS", "8080|80")
+	var ve *env.ValidationError
+	err := parser.Unmarshal(&cfg)
+	if !errors.As(err, &ve) || ve.Field != "Ports" || !strings.Contains(err.Error(), "invalid value: 80.") {
+		t.Errorf("expected a ValidationError for the slice element, got %v", err)
+	}
+
+	docs, _ := parser.Document(&cfg)
+	if len(docs) != 2 || len(docs[0].Validators) != 1 || docs[0].Validators[0] != "v_port_range" {
+		t.Errorf("expected the custom validator in the docs, got %+v", docs)
+	}
+}
+
+type validateScaling struct {
+	MinReplicas int `env:"name=VALIDATE_MIN_REPLICAS,default=1"`
+	MaxReplicas int `env:"name=VALIDATE_MAX_REPLICAS,default=3"`
+}
+
+func (s validateScaling) Validate() error {
+	if s.MinReplicas > s.MaxReplicas {
+		return fmt.Errorf("MinReplicas (%d) must not exceed MaxReplicas (%d)", s.MinReplicas, s.MaxReplicas)
+	}
+	return nil
+}
+
+type validateConfig struct {
+	Name    string `env:"name=VALIDATE_NAME,default=app"`
+	Scaling validateScaling
+}
+
+func (c *validateConfig) Validate() error {
+	if c.Name == "forbidden" {
+		return errors.New("name is reserved")
+	}
+	return nil
+}
+
+func TestValidateHook(t *testing.T) {
+	var cfg validateConfig
+	if err := env.NewParser().Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	os.Setenv("VALIDATE_MIN_REPLICAS", "5")
+	os.Setenv("VALIDATE_NAME", "forbidden")
+	defer os.Unsetenv("VALIDATE_MIN_REPLICAS")
+	defer os.Unsetenv("VALIDATE_NAME")
+
+	err := env.NewParser().Unmarshal(&cfg)
+	want := "validateConfig.Scaling: MinReplicas (5) must not exceed MaxReplicas (3)\nvalidateConfig: name is reserved"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+	var ve *env.ValidationError
+	if !errors.As(err, &ve) || ve.Field != "Scaling" {
+		t.Errorf("expected a ValidationError for Scaling, got %#v", ve)
+	}
+}
+
+// ValidateLimits is exported so it can be embedded in populated structs.
+type ValidateLimits struct {
+	Min int `env:"name=VALIDATE_LIMIT_MIN,default=1"`
+	Max int `env:"name=VALIDATE_LIMIT_MAX,default=3"`
+}
+
+func (l *ValidateLimits) Validate() error {
+	if l.Min > l.Max {
+		return fmt.Errorf("Min (%d) must not exceed Max (%d)", l.Min, l.Max)
+	}
+	return nil
+}
+
+type validateCounter struct {
+	Calls *int
+}
+
+func (c validateCounter) Validate() error {
+	*c.Calls++
+	return nil
+}
+
+// validateShadow declares a Validate method shadowing the one of the embedded struct.
+type validateShadow struct {
+	ValidateLimits
+}
+
+func (s *validateShadow) Validate() error {
+	return errors.New("shadowed")
+}
+
+func TestValidateHookEmbeddedAndSkipped(t *testing.T) {
+	type Config struct {
+		ValidateLimits
+		Counter validateCounter `env:"-"`
+	}
+
+	os.Setenv("VALIDATE_LIMIT_MIN", "5")
+	defer os.Unsetenv("VALIDATE_LIMIT_MIN")
+
+	calls := 0
+	cfg := Config{Counter: validateCounter{Calls: &calls}}
+	err := env.NewParser().Unmarshal(&cfg)
+	want := "Config: Min (5) must not exceed Max (3)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected the promoted Validate to run once with error %q, got %v", want, err)
+	}
+
+	// A Validate method declared on the embedding struct shadows the embedded one, as in Go
+	type Shadowing struct {
+		Limits validateShadow
+	}
+	err = env.NewParser().Unmarshal(&Shadowing{})
+	want = "Shadowing.Limits: shadowed"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected only the declared Validate to run with error %q, got %v", want, err)
+	}
+	if calls != 0 {
+		t.Errorf("expected the skipped field not to be validated, got %d calls", calls)
+	}
+
+	type Compat struct {
+		Counter validateCounter `ignored:"true"`
+	}
+	if err := env.NewParser().WithEnvconfigTags(true).Unmarshal(&Compat{Counter: validateCounter{Calls: &calls}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected the ignored field not to be validated, got %d calls", calls)
+	}
+}
+
+func TestMetadataCacheHonorsConfiguration(t *testing.T) {
+	type Config struct {
+		MaxConns int      `env:"default=1"`
+		Hosts    []string `env:"name=CACHE_HOSTS;default=a,b"`
+	}
+
+	os.Setenv("A_MAXCONNS", "2")
+	os.Setenv("B_MAX_CONNS", "3")
+	defer os.Unsetenv("A_MAXCONNS")
+	defer os.Unsetenv("B_MAX_CONNS")
+
+	var cfg Config
+	parser := env.NewParser().WithTagOptionSeparator(";").WithSliceValueSeparator(",")
+	for _, c := range []struct {
+		parser *env.Parser
+		want   int
+	}{
+		{parser.WithNamePrefix("A_"), 2},
+		{env.NewParser().WithTagOptionSeparator(";").WithSliceValueSeparator(",").WithNamePrefix("B_").WithSnakeCaseNames(), 3},
+		{env.NewParser().WithTagOptionSeparator(";").WithSliceValueSeparator(",").WithNamePrefix("A_"), 2},
+	} {
+		if err := c.parser.Unmarshal(&cfg); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if cfg.MaxConns != c.want || strings.Join(cfg.Hosts, "|") != "a|b" {
+			t.Errorf("expected MaxConns %d and hosts a|b, got %+v", c.want, cfg)
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	type Config struct {
+		Host    string        `env:"name=BENCH_HOST,default=localhost"`
+		Port    int           `env:"name=BENCH_PORT,default=8080,min=1,max=65535"`
+		Timeout time.Duration `env:"name=BENCH_TIMEOUT,default=5s"`
+		Tags    []string      `env:"name=BENCH_TAGS,default=a|b|c"`
+		DB      struct {
+			Name string `env:"name=NAME,default=app,regex=^[a-z]+$"`
+		} `env:"prefix=DB_"`
+	}
+
+	parser := env.NewParser()
+	for i := 0; i < b.N; i++ {
+		var cfg Config
+		if err := parser.Unmarshal(&cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParserClone(t *testing.T) {
+	type Config struct {
+		Host string `env:"name=HOST"`
+		Port int    `env:"name=PORT,default=1,v_even"`
+	}
+
+	os.Setenv("API_HOST", "api")
+	os.Setenv("WORKER_HOST", "worker")
+	defer os.Unsetenv("API_HOST")
+	defer os.Unsetenv("WORKER_HOST")
+
+	base := env.NewParser().RegisterValidator("v_even", func(string) error { return nil })
+	api := base.Clone().WithNamePrefix("API_")
+	worker := base.Clone().WithNamePrefix("WORKER_").
+		RegisterValidator("v_even", func(val string) error { return errors.New("odd") })
+
+	if base.NamePrefix != "" || api.NamePrefix != "API_" {
+		t.Errorf("expected clones to be configured independently, got %q and %q", base.NamePrefix, api.NamePrefix)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var cfg Config
+			if err := api.Unmarshal(&cfg); err != nil {
+				results[i] = err.Error()
+				return
+			}
+			results[i] = cfg.Host
+		}(i)
+	}
+	wg.Wait()
+	for _, r := range results {
+		if r != "api" {
+			t.Fatalf("expected api, got %s", r)
+		}
+	}
+
+	var cfg Config
+	if err := worker.Unmarshal(&cfg); err == nil {
+		t.Errorf("expected the worker's validator to fail")
+	}
+	if err := base.Unmarshal(&cfg); err != nil {
+		t.Errorf("expected the base validator to be unchanged, got %v", err)
+	}
+}
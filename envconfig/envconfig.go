@@ -0,0 +1,44 @@
+// Package envconfig provides the Process function of github.com/kelseyhightower/envconfig on top of env.Parser,
+// so services can migrate by changing an import path and keep their struct tags:
+//
+//	type Specification struct {
+//	    Port     int           `default:"8080"`
+//	    MaxConns int           `split_words:"true" required:"true"`
+//	    Timeout  time.Duration `envconfig:"REQUEST_TIMEOUT" default:"5s"`
+//	}
+//
+//	var s Specification
+//	err := envconfig.Process("myapp", &s) // MYAPP_PORT, MYAPP_MAX_CONNS, MYAPP_REQUEST_TIMEOUT
+//
+// Errors are those of env.Parser, and the features of this module (e.g., Document or PrintTable) are available
+// through NewParser.
+package envconfig
+
+import (
+	"strings"
+
+	"github.com/igwtcode/go-env"
+)
+
+// NewParser returns a parser reading envconfig style tags (see env.Parser.WithEnvconfigTags), with names
+// prefixed by the upper-cased prefix and an underscore unless the prefix is empty. As with envconfig, variables
+// set to an empty value do not fall back to their defaults.
+func NewParser(prefix string) *env.Parser {
+	p := env.NewParser().WithEnvconfigTags(true).WithEmptyIsSet(true)
+	if prefix != "" {
+		p.WithNamePrefix(strings.ToUpper(prefix) + "_")
+	}
+	return p
+}
+
+// Process populates the specification, a pointer to a struct, from environment variables with the given prefix.
+func Process(prefix string, spec interface{}) error {
+	return NewParser(prefix).Unmarshal(spec)
+}
+
+// MustProcess is like Process but panics if the specification cannot be populated.
+func MustProcess(prefix string, spec interface{}) {
+	if err := Process(prefix, spec); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,76 @@
+package envconfig_test
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+	"github.com/igwtcode/go-env/envconfig"
+)
+
+type Database struct {
+	Host string `required:"true"`
+	Port int    `default:"5432"`
+}
+
+type Embedded struct {
+	Region string `default:"eu-west-1"`
+}
+
+type Specification struct {
+	Embedded
+	Debug    bool
+	Port     int           `default:"8080"`
+	MaxConns int           `split_words:"true"`
+	Users    []string      `envconfig:"ADMIN_USERS"`
+	Timeout  time.Duration `envconfig:"REQUEST_TIMEOUT" default:"5s"`
+	Token    string        `envconfig:"API_TOKEN"`
+	Internal string        `ignored:"true"`
+	Database Database
+}
+
+func TestProcess(t *testing.T) {
+	vars := map[string]string{
+		"MYAPP_DEBUG":         "true",
+		"MYAPP_MAX_CONNS":     "10",
+		"MYAPP_ADMIN_USERS":   "alice,bob",
+		"API_TOKEN":           "token",
+		"MYAPP_INTERNAL":      "x",
+		"MYAPP_DATABASE_HOST": "db",
+		"MYAPP_PORT":          "",
+	}
+	for name, val := range vars {
+		os.Setenv(name, val)
+		defer os.Unsetenv(name)
+	}
+
+	var s Specification
+	if err := envconfig.Process("myapp", &s); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !s.Debug || s.MaxConns != 10 || !slices.Equal(s.Users, []string{"alice", "bob"}) || s.Token != "token" {
+		t.Errorf("unexpected values: %+v", s)
+	}
+	if s.Timeout != 5*time.Second || s.Region != "eu-west-1" || s.Database.Host != "db" || s.Database.Port != 5432 {
+		t.Errorf("unexpected values: %+v", s)
+	}
+	if s.Port != 0 {
+		t.Errorf("expected a variable set to an empty value to skip the default, got %d", s.Port)
+	}
+	if s.Internal != "" {
+		t.Errorf("expected the ignored field to be left unchanged, got %q", s.Internal)
+	}
+}
+
+func TestProcessRequired(t *testing.T) {
+	os.Unsetenv("MYAPP_DATABASE_HOST")
+
+	err := envconfig.Process("myapp", &Specification{})
+	var reqErr *env.RequiredError
+	if !errors.As(err, &reqErr) || reqErr.Field != "Database.Host" || reqErr.Names[0] != "MYAPP_DATABASE_HOST" {
+		t.Errorf("expected a required error for MYAPP_DATABASE_HOST, got %v", err)
+	}
+}
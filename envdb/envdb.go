@@ -0,0 +1,115 @@
+// Package envdb provides a ready-made, environment-driven configuration for SQL database connections.
+package envdb
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+// DBConfig holds the connection and pool settings of a database, read from DB_* environment variables.
+//
+// The password can be given directly in DB_PASSWORD or, preferably, as the path of a file
+// containing it in DB_PASSWORD_FILE (e.g., a Docker or Kubernetes secret).
+type DBConfig struct {
+	Host            string        `env:"name=DB_HOST,default=localhost"`
+	Port            int           `env:"name=DB_PORT,min=0,max=65535,default=0"`
+	User            string        `env:"name=DB_USER,required"`
+	Password        string        `env:"name=DB_PASSWORD,sensitive"`
+	PasswordFile    string        `env:"name=DB_PASSWORD_FILE"`
+	Name            string        `env:"name=DB_NAME,required"`
+	SSLMode         string        `env:"name=DB_SSLMODE,lower,oneof=disable|allow|prefer|require|verify-ca|verify-full,default=require"`
+	MaxOpenConns    int           `env:"name=DB_MAX_OPEN_CONNS,min=0,default=10"`
+	MaxIdleConns    int           `env:"name=DB_MAX_IDLE_CONNS,min=0,default=5"`
+	ConnMaxLifetime time.Duration `env:"name=DB_CONN_MAX_LIFETIME,default=30m"`
+	ConnMaxIdleTime time.Duration `env:"name=DB_CONN_MAX_IDLE_TIME,default=5m"`
+}
+
+// Load reads the database configuration from the environment using the parser and resolves
+// the password file, if any. A nil parser uses env.NewParser().
+func Load(p *env.Parser) (*DBConfig, error) {
+	if p == nil {
+		p = env.NewParser()
+	}
+	var cfg DBConfig
+	if err := p.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.ResolvePassword(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ResolvePassword reads the password from PasswordFile when Password is empty,
+// trimming the trailing newline most editors and secret stores add.
+func (c *DBConfig) ResolvePassword() error {
+	if c.Password != "" || c.PasswordFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(c.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("failed to read DB_PASSWORD_FILE: %w", err)
+	}
+	c.Password = strings.TrimRight(string(b), "\r\n")
+	return nil
+}
+
+// DSN builds the data source name for the given driver: "postgres" (or "pgx") and "mysql" are supported.
+// An empty string is returned for other drivers. A zero port uses the driver's default port. The user and
+// password are escaped in the postgres URL, and written as is for mysql, whose driver splits the DSN at the
+// last @ and does not unescape them.
+func (c *DBConfig) DSN(driver string) string {
+	switch driver {
+	case "postgres", "postgresql", "pgx":
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.port(5432))),
+			Path:     "/" + c.Name,
+			RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+		}
+		return u.String()
+	case "mysql":
+		query := url.Values{"parseTime": {"true"}, "tls": {mysqlTLS(c.SSLMode)}}
+		return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", c.User, c.Password, net.JoinHostPort(c.Host, strconv.Itoa(c.port(3306))), c.Name, query.Encode())
+	}
+	return ""
+}
+
+// ApplyPool applies the pool settings to the database handle.
+func (c *DBConfig) ApplyPool(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// port returns the configured port, or the driver's default port when unset.
+func (c *DBConfig) port(def int) int {
+	if c.Port == 0 {
+		return def
+	}
+	return c.Port
+}
+
+// mysqlTLS maps a PostgreSQL-style sslmode to the tls parameter of the MySQL driver.
+func mysqlTLS(sslmode string) string {
+	switch sslmode {
+	case "disable", "allow":
+		return "false"
+	case "prefer":
+		return "preferred"
+	case "verify-ca", "verify-full":
+		return "true"
+	default:
+		return "skip-verify"
+	}
+}
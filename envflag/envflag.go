@@ -0,0 +1,72 @@
+// Package envflag binds env-tagged structs to command-line flags of spf13/pflag flag sets (and therefore cobra
+// commands), so CLIs accept every setting both as a flag and as an environment variable.
+//
+// The package does not depend on pflag: *pflag.FlagSet satisfies the FlagSet interface.
+package envflag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/igwtcode/go-env"
+)
+
+// FlagSet is the subset of *pflag.FlagSet used to define and query flags.
+type FlagSet interface {
+	StringVar(p *string, name string, value string, usage string)
+	Changed(name string) bool
+}
+
+// Bind defines a flag for every field of the struct read from environment variables and returns a source
+// reporting the flags set on the command line, to put first in env.Parser.WithSources. Flags are named after
+// the first variable name of the field without the parser's name prefix, in lower case with dashes (e.g.,
+// APP_DB_HOST becomes --db-host), and their help text is generated from the tags. A nil parser uses
+// env.NewParser().
+//
+// Flags left unset are not reported, so the environment and the `default` options still apply.
+func Bind(fs FlagSet, p *env.Parser, envStruct interface{}) (env.Source, error) {
+	if p == nil {
+		p = env.NewParser()
+	}
+	docs, err := p.Document(envStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]*string{} // Flag values by variable name
+	flags := map[string]string{}   // Flag names by variable name
+	for _, d := range docs {
+		name := FlagName(d.Names[0], p.NamePrefix)
+		val := new(string)
+		fs.StringVar(val, name, d.Default, usage(d))
+		for _, n := range d.Names {
+			values[n], flags[n] = val, name
+		}
+	}
+
+	return env.SourceFunc(func(key string) (string, bool) {
+		name, ok := flags[key]
+		if !ok || !fs.Changed(name) {
+			return "", false
+		}
+		return *values[key], true
+	}), nil
+}
+
+// FlagName returns the flag name for a variable: without the prefix, in lower case with dashes.
+func FlagName(envName, prefix string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(envName, prefix)), "_", "-")
+}
+
+// usage returns the help text of a field's flag.
+func usage(d env.FieldDoc) string {
+	details := []string{fmt.Sprintf("%s (env %s)", d.Type, d.Names[0])}
+	if d.Required {
+		details = append(details, "required")
+	}
+	if d.Sensitive {
+		details = append(details, "sensitive")
+	}
+	details = append(details, d.Validators...)
+	return strings.Join(details, "; ")
+}
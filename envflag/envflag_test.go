@@ -0,0 +1,74 @@
+package envflag_test
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+	"github.com/igwtcode/go-env/envflag"
+)
+
+// stdFlags adapts a standard library flag set to envflag.FlagSet, the way *pflag.FlagSet satisfies it.
+type stdFlags struct {
+	*flag.FlagSet
+}
+
+func (s stdFlags) Changed(name string) bool {
+	changed := false
+	s.Visit(func(f *flag.Flag) {
+		changed = changed || f.Name == name
+	})
+	return changed
+}
+
+type Config struct {
+	Host     string `env:"name=DB_HOST,default=localhost"`
+	Port     int    `env:"name=DB_PORT,default=5432,min=1"`
+	Password string `env:"name=DB_PASSWORD,required,sensitive"`
+	Mode     string `env:"name=MODE,oneof=safe|fast"`
+}
+
+func TestBind(t *testing.T) {
+	fs := stdFlags{flag.NewFlagSet("app", flag.ContinueOnError)}
+	parser := env.NewParser().WithNamePrefix("APP_")
+	src, err := envflag.Bind(fs, parser, &Config{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	f := fs.Lookup("db-port")
+	if f == nil || f.DefValue != "5432" || f.Usage != "int (env APP_DB_PORT); min=1" {
+		t.Fatalf("unexpected db-port flag: %+v", f)
+	}
+	if f := fs.Lookup("db-password"); f == nil || f.Usage != "string (env APP_DB_PASSWORD); required; sensitive" {
+		t.Fatalf("unexpected db-password flag: %+v", f)
+	}
+
+	if err := fs.Parse([]string{"--db-host", "flag-host", "--mode", "fast"}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("APP_DB_HOST", "env-host")
+	os.Setenv("APP_DB_PASSWORD", "secret")
+	defer os.Unsetenv("APP_DB_HOST")
+	defer os.Unsetenv("APP_DB_PASSWORD")
+
+	var cfg Config
+	if err := parser.WithSources(src, env.EnvSource()).Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "flag-host" || cfg.Mode != "fast" {
+		t.Errorf("expected flags to override the environment, got %+v", cfg)
+	}
+	if cfg.Port != 5432 || cfg.Password != "secret" {
+		t.Errorf("expected unset flags to fall back to the environment and defaults, got %+v", cfg)
+	}
+}
+
+func TestBindInvalid(t *testing.T) {
+	fs := stdFlags{flag.NewFlagSet("app", flag.ContinueOnError)}
+	if _, err := envflag.Bind(fs, nil, "config"); err == nil || !strings.Contains(err.Error(), "expected a struct") {
+		t.Errorf("expected an error for a non-struct value, got %v", err)
+	}
+}
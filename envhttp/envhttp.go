@@ -0,0 +1,68 @@
+// Package envhttp provides a ready-made, environment-driven configuration for net/http servers.
+package envhttp
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+// ServerConfig holds the common settings of an HTTP server, read from HTTP_* environment variables.
+type ServerConfig struct {
+	Addr              string        `env:"name=HTTP_ADDR,default=:8080"`
+	ReadTimeout       time.Duration `env:"name=HTTP_READ_TIMEOUT,default=15s"`
+	ReadHeaderTimeout time.Duration `env:"name=HTTP_READ_HEADER_TIMEOUT,default=5s"`
+	WriteTimeout      time.Duration `env:"name=HTTP_WRITE_TIMEOUT,default=15s"`
+	IdleTimeout       time.Duration `env:"name=HTTP_IDLE_TIMEOUT,default=60s"`
+	ShutdownTimeout   time.Duration `env:"name=HTTP_SHUTDOWN_TIMEOUT,default=10s"`
+	MaxHeaderBytes    int           `env:"name=HTTP_MAX_HEADER_BYTES,bytesize,min=1024,default=1MiB"`
+	TLSCertFile       string        `env:"name=HTTP_TLS_CERT_FILE"`
+	TLSKeyFile        string        `env:"name=HTTP_TLS_KEY_FILE"`
+}
+
+// Load reads the server configuration from the environment using the parser and validates it.
+// A nil parser uses env.NewParser().
+func Load(p *env.Parser) (*ServerConfig, error) {
+	if p == nil {
+		p = env.NewParser()
+	}
+	var cfg ServerConfig
+	// Unmarshal also runs Validate
+	if err := p.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks the settings that depend on each other.
+func (c *ServerConfig) Validate() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// TLSEnabled reports whether a certificate and key are configured.
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// ApplyTo copies the address, timeouts and header limit to the server.
+func (c *ServerConfig) ApplyTo(s *http.Server) {
+	s.Addr = c.Addr
+	s.ReadTimeout = c.ReadTimeout
+	s.ReadHeaderTimeout = c.ReadHeaderTimeout
+	s.WriteTimeout = c.WriteTimeout
+	s.IdleTimeout = c.IdleTimeout
+	s.MaxHeaderBytes = c.MaxHeaderBytes
+}
+
+// ListenAndServe starts the server with TLS when a certificate and key are configured.
+func (c *ServerConfig) ListenAndServe(s *http.Server) error {
+	if c.TLSEnabled() {
+		return s.ListenAndServeTLS(c.TLSCertFile, c.TLSKeyFile)
+	}
+	return s.ListenAndServe()
+}
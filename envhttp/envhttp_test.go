@@ -0,0 +1,75 @@
+package envhttp_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+	"github.com/igwtcode/go-env/envhttp"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := envhttp.Load(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Addr != ":8080" {
+		t.Errorf("expected Addr to be ':8080', got %v", cfg.Addr)
+	}
+	if cfg.MaxHeaderBytes != 1<<20 {
+		t.Errorf("expected MaxHeaderBytes to be 1MiB, got %v", cfg.MaxHeaderBytes)
+	}
+	if cfg.TLSEnabled() {
+		t.Errorf("expected TLS to be disabled")
+	}
+}
+
+func TestLoadWithPrefixAndApply(t *testing.T) {
+	os.Setenv("API_HTTP_ADDR", ":9000")
+	os.Setenv("API_HTTP_READ_TIMEOUT", "3s")
+	os.Setenv("API_HTTP_MAX_HEADER_BYTES", "64KiB")
+	os.Setenv("API_HTTP_TLS_CERT_FILE", "/etc/tls/cert.pem")
+	os.Setenv("API_HTTP_TLS_KEY_FILE", "/etc/tls/key.pem")
+	defer os.Unsetenv("API_HTTP_ADDR")
+	defer os.Unsetenv("API_HTTP_READ_TIMEOUT")
+	defer os.Unsetenv("API_HTTP_MAX_HEADER_BYTES")
+	defer os.Unsetenv("API_HTTP_TLS_CERT_FILE")
+	defer os.Unsetenv("API_HTTP_TLS_KEY_FILE")
+
+	cfg, err := envhttp.Load(env.NewParser().WithNamePrefix("API_"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var srv http.Server
+	cfg.ApplyTo(&srv)
+
+	if srv.Addr != ":9000" {
+		t.Errorf("expected Addr to be ':9000', got %v", srv.Addr)
+	}
+	if srv.ReadTimeout != 3*time.Second {
+		t.Errorf("expected ReadTimeout to be 3s, got %v", srv.ReadTimeout)
+	}
+	if srv.IdleTimeout != 60*time.Second {
+		t.Errorf("expected IdleTimeout to be 60s, got %v", srv.IdleTimeout)
+	}
+	if srv.MaxHeaderBytes != 64*1024 {
+		t.Errorf("expected MaxHeaderBytes to be 65536, got %v", srv.MaxHeaderBytes)
+	}
+	if !cfg.TLSEnabled() {
+		t.Errorf("expected TLS to be enabled")
+	}
+}
+
+func TestLoadWithIncompleteTLS(t *testing.T) {
+	os.Setenv("HTTP_TLS_CERT_FILE", "/etc/tls/cert.pem")
+	defer os.Unsetenv("HTTP_TLS_CERT_FILE")
+
+	_, err := envhttp.Load(nil)
+	if err == nil {
+		t.Fatalf("expected an error for a certificate without key, got none")
+	}
+}
@@ -0,0 +1,77 @@
+// Package envsecrets resolves `secretsmanager://` references from AWS Secrets Manager, optionally extracting
+// a key of a JSON secret.
+//
+// The package does not depend on the AWS SDK: the Client interface is satisfied by a small adapter around
+// *secretsmanager.Client, e.g.:
+//
+//	client := secretsmanager.NewFromConfig(awsCfg)
+//	getter := envsecrets.ClientFunc(func(ctx context.Context, id string) (string, error) {
+//		out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &id})
+//		if err != nil {
+//			return "", err
+//		}
+//		return *out.SecretString, nil
+//	})
+//	parser := env.NewParser().WithResolver(envsecrets.Scheme, envsecrets.Resolver(getter))
+package envsecrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/igwtcode/go-env"
+)
+
+// Scheme is the scheme of Secrets Manager references, e.g., secretsmanager://my-secret#password.
+const Scheme = "secretsmanager"
+
+// Client fetches the string value of a secret by name or ARN.
+type Client interface {
+	GetSecretValue(ctx context.Context, id string) (string, error)
+}
+
+// ClientFunc adapts a function to a Client.
+type ClientFunc func(ctx context.Context, id string) (string, error)
+
+// GetSecretValue calls f.
+func (f ClientFunc) GetSecretValue(ctx context.Context, id string) (string, error) {
+	return f(ctx, id)
+}
+
+// Resolver returns a resolver for `secretsmanager://` references, to register with env.Parser.WithResolver.
+// The reference is the secret name or ARN, optionally followed by `#key` to use a key of a secret holding a
+// JSON object (e.g., secretsmanager://prod/db#password). String values are used as is, other JSON values in
+// their JSON encoding.
+func Resolver(c Client) env.ResolverFunc {
+	return func(ctx context.Context, ref string) (string, error) {
+		id, key, hasKey := strings.Cut(ref, "#")
+		val, err := c.GetSecretValue(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("getting secret %s: %w", id, err)
+		}
+		if !hasKey {
+			return val, nil
+		}
+		return extract(val, id, key)
+	}
+}
+
+// extract returns the value of a key of a secret holding a JSON object. Parse errors are not wrapped, as they
+// may quote parts of the secret.
+func extract(secret, id, key string) (string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(secret), &obj); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", id)
+	}
+	raw, ok := obj[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", id, key)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return string(raw), nil
+}
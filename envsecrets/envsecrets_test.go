@@ -0,0 +1,63 @@
+package envsecrets_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+	"github.com/igwtcode/go-env/envsecrets"
+)
+
+func TestResolver(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+		Port     int    `env:"name=DB_PORT"`
+		APIKey   string `env:"name=API_KEY"`
+	}
+
+	secrets := map[string]string{
+		"prod/db":  `{"password":"s3cret","port":5432}`,
+		"prod/api": "plain-key",
+	}
+	client := envsecrets.ClientFunc(func(ctx context.Context, id string) (string, error) {
+		val, ok := secrets[id]
+		if !ok {
+			return "", errors.New("ResourceNotFoundException")
+		}
+		return val, nil
+	})
+	parser := env.NewParser().WithResolver(envsecrets.Scheme, envsecrets.Resolver(client))
+
+	vars := map[string]string{
+		"DB_PASSWORD": "secretsmanager://prod/db#password",
+		"DB_PORT":     "secretsmanager://prod/db#port",
+		"API_KEY":     "secretsmanager://prod/api",
+	}
+	for k, v := range vars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Port != 5432 || cfg.APIKey != "plain-key" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	tests := map[string]string{
+		"secretsmanager://prod/db#user":    "secret prod/db has no key user",
+		"secretsmanager://prod/api#key":    "secret prod/api is not a JSON object",
+		"secretsmanager://prod/missing#id": "getting secret prod/missing: ResourceNotFoundException",
+	}
+	for ref, want := range tests {
+		os.Setenv("API_KEY", ref)
+		if err := parser.Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", ref, want, err)
+		}
+	}
+}
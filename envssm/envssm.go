@@ -0,0 +1,78 @@
+// Package envssm reads values from AWS Systems Manager Parameter Store, either per field from `ssm://`
+// references or for all parameters under a path.
+//
+// The package does not depend on the AWS SDK: the Client and PathClient interfaces are satisfied by small
+// adapters around *ssm.Client, e.g.:
+//
+//	client := ssm.NewFromConfig(awsCfg)
+//	getter := envssm.ClientFunc(func(ctx context.Context, name string, decrypt bool) (string, error) {
+//		out, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: &name, WithDecryption: &decrypt})
+//		if err != nil {
+//			return "", err
+//		}
+//		return *out.Parameter.Value, nil
+//	})
+//	parser := env.NewParser().WithResolver(envssm.Scheme, envssm.Resolver(getter))
+package envssm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/igwtcode/go-env"
+)
+
+// Scheme is the scheme of Parameter Store references, e.g., ssm:///my/app/db_password.
+const Scheme = "ssm"
+
+// Client fetches a single parameter by name, decrypting SecureString values when decrypt is true.
+type Client interface {
+	GetParameter(ctx context.Context, name string, decrypt bool) (string, error)
+}
+
+// ClientFunc adapts a function to a Client.
+type ClientFunc func(ctx context.Context, name string, decrypt bool) (string, error)
+
+// GetParameter calls f.
+func (f ClientFunc) GetParameter(ctx context.Context, name string, decrypt bool) (string, error) {
+	return f(ctx, name, decrypt)
+}
+
+// PathClient fetches all parameters under a path, recursively, returning their values by full name.
+type PathClient interface {
+	GetParametersByPath(ctx context.Context, path string, decrypt bool) (map[string]string, error)
+}
+
+// Resolver returns a resolver for `ssm://` references, to register with env.Parser.WithResolver. The reference
+// is the parameter name (e.g., /my/app/db_password), and SecureString values are decrypted.
+func Resolver(c Client) env.ResolverFunc {
+	return func(ctx context.Context, ref string) (string, error) {
+		val, err := c.GetParameter(ctx, ref, true)
+		if err != nil {
+			return "", fmt.Errorf("getting parameter %s: %w", ref, err)
+		}
+		return val, nil
+	}
+}
+
+// Load fetches all parameters under the path, with SecureString values decrypted, and returns a source exposing
+// them as variables named after the parameter name relative to the path, in upper case with underscores (e.g.,
+// /my/app/db/password under /my/app becomes DB_PASSWORD).
+func Load(ctx context.Context, c PathClient, path string) (env.Source, error) {
+	params, err := c.GetParametersByPath(ctx, path, true)
+	if err != nil {
+		return nil, fmt.Errorf("getting parameters under %s: %w", path, err)
+	}
+	vars := map[string]string{}
+	for name, val := range params {
+		vars[VarName(name, path)] = val
+	}
+	return env.MapSource(vars), nil
+}
+
+// VarName returns the variable name of a parameter under the path.
+func VarName(name, path string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(name, strings.TrimSuffix(path, "/")), "/")
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(rel))
+}
@@ -0,0 +1,95 @@
+package envssm_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+	"github.com/igwtcode/go-env/envssm"
+)
+
+// fakeSSM serves parameters from a map, recording whether values were requested decrypted.
+type fakeSSM struct {
+	params    map[string]string
+	decrypted bool
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, name string, decrypt bool) (string, error) {
+	f.decrypted = decrypt
+	val, ok := f.params[name]
+	if !ok {
+		return "", errors.New("ParameterNotFound")
+	}
+	return val, nil
+}
+
+func (f *fakeSSM) GetParametersByPath(ctx context.Context, path string, decrypt bool) (map[string]string, error) {
+	f.decrypted = decrypt
+	out := map[string]string{}
+	for name, val := range f.params {
+		if strings.HasPrefix(name, path+"/") {
+			out[name] = val
+		}
+	}
+	return out, nil
+}
+
+func TestResolver(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+		Host     string `env:"name=DB_HOST"`
+	}
+
+	client := &fakeSSM{params: map[string]string{"/my/app/db_password": "s3cret"}}
+	parser := env.NewParser().WithResolver(envssm.Scheme, envssm.Resolver(client))
+
+	os.Setenv("DB_PASSWORD", "ssm:///my/app/db_password")
+	os.Setenv("DB_HOST", "localhost")
+	defer os.Unsetenv("DB_PASSWORD")
+	defer os.Unsetenv("DB_HOST")
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Host != "localhost" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if !client.decrypted {
+		t.Errorf("expected the parameter to be decrypted")
+	}
+
+	os.Setenv("DB_PASSWORD", "ssm:///my/app/missing")
+	err := parser.Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Config.Password (DB_PASSWORD): resolving ssm reference: getting parameter /my/app/missing: ParameterNotFound") {
+		t.Errorf("expected a resolution error, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+		Region   string `env:"name=REGION"`
+	}
+
+	client := &fakeSSM{params: map[string]string{
+		"/my/app/db/password": "s3cret",
+		"/my/app/region":      "eu-west-1",
+		"/other/region":       "us-east-1",
+	}}
+	src, err := envssm.Load(context.Background(), client, "/my/app")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var cfg Config
+	if err := env.NewParser().WithSources(src).Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Region != "eu-west-1" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
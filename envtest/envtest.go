@@ -0,0 +1,81 @@
+// Package envtest provides helpers to set environment variables in tests and to check that they cover a
+// configuration struct.
+package envtest
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+)
+
+// Set sets the environment variables for the duration of the test. Previous values are restored, and variables
+// that were unset are removed again, when the test and its subtests complete. Like t.Setenv, it must not be used
+// in parallel tests, as the environment is shared by the process.
+func Set(t testing.TB, vars map[string]string) {
+	t.Helper()
+	for name, val := range vars {
+		restore(t, name)
+		if err := os.Setenv(name, val); err != nil {
+			t.Fatalf("setting %s: %v", name, err)
+		}
+	}
+}
+
+// Unset removes the environment variables for the duration of the test, restoring them when it completes.
+func Unset(t testing.TB, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		restore(t, name)
+		os.Unsetenv(name)
+	}
+}
+
+// restore registers a cleanup function resetting the variable to its current state.
+func restore(t testing.TB, name string) {
+	prev, ok := os.LookupEnv(name)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(name, prev)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+// AssertRequiredSet reports a test error listing the required fields of the struct without a default whose
+// variables are all unset or empty, so tests fail with the full list instead of the first missing variable.
+// A nil parser uses env.NewParser().
+func AssertRequiredSet(t testing.TB, p *env.Parser, envStruct interface{}) {
+	t.Helper()
+	if p == nil {
+		p = env.NewParser()
+	}
+	docs, err := p.Document(envStruct)
+	if err != nil {
+		t.Fatalf("documenting %T: %v", envStruct, err)
+	}
+
+	var missing []string
+	for _, d := range docs {
+		if d.Required && d.Default == "" && !anySet(d.Names) {
+			missing = append(missing, d.Field+" ("+d.Names[0]+")")
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		t.Errorf("required variables not set: %s", strings.Join(missing, "; "))
+	}
+}
+
+// anySet reports whether one of the variables is set to a non-empty value.
+func anySet(names []string) bool {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
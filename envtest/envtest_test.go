@@ -0,0 +1,79 @@
+package envtest_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/igwtcode/go-env/envtest"
+)
+
+func TestSetRestores(t *testing.T) {
+	os.Setenv("ENVTEST_EXISTING", "before")
+	os.Unsetenv("ENVTEST_NEW")
+	defer os.Unsetenv("ENVTEST_EXISTING")
+
+	t.Run("scoped", func(t *testing.T) {
+		envtest.Set(t, map[string]string{"ENVTEST_EXISTING": "during", "ENVTEST_NEW": "value"})
+		if os.Getenv("ENVTEST_EXISTING") != "during" || os.Getenv("ENVTEST_NEW") != "value" {
+			t.Errorf("expected the variables to be set")
+		}
+	})
+
+	if got := os.Getenv("ENVTEST_EXISTING"); got != "before" {
+		t.Errorf("expected ENVTEST_EXISTING to be restored to 'before', got '%s'", got)
+	}
+	if _, ok := os.LookupEnv("ENVTEST_NEW"); ok {
+		t.Errorf("expected ENVTEST_NEW to be unset again")
+	}
+}
+
+func TestUnsetRestores(t *testing.T) {
+	os.Setenv("ENVTEST_EXISTING", "before")
+	defer os.Unsetenv("ENVTEST_EXISTING")
+
+	t.Run("scoped", func(t *testing.T) {
+		envtest.Unset(t, "ENVTEST_EXISTING")
+		if _, ok := os.LookupEnv("ENVTEST_EXISTING"); ok {
+			t.Errorf("expected ENVTEST_EXISTING to be unset")
+		}
+	})
+
+	if got := os.Getenv("ENVTEST_EXISTING"); got != "before" {
+		t.Errorf("expected ENVTEST_EXISTING to be restored to 'before', got '%s'", got)
+	}
+}
+
+// recorder captures the errors reported through testing.TB.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertRequiredSet(t *testing.T) {
+	type Config struct {
+		Host  string `env:"name=ENVTEST_HOST,required"`
+		Token string `env:"name=ENVTEST_TOKEN|ENVTEST_API_TOKEN,required"`
+		Port  int    `env:"name=ENVTEST_PORT,required,default=8080"`
+		Mode  string `env:"name=ENVTEST_MODE"`
+	}
+	envtest.Unset(t, "ENVTEST_HOST", "ENVTEST_TOKEN", "ENVTEST_API_TOKEN", "ENVTEST_PORT")
+
+	r := &recorder{TB: t}
+	envtest.AssertRequiredSet(r, nil, &Config{})
+	want := "required variables not set: Host (ENVTEST_HOST); Token (ENVTEST_TOKEN)"
+	if len(r.errors) != 1 || r.errors[0] != want {
+		t.Errorf("expected error %q, got %v", want, r.errors)
+	}
+
+	envtest.Set(t, map[string]string{"ENVTEST_HOST": "localhost", "ENVTEST_API_TOKEN": "token"})
+	r = &recorder{TB: t}
+	envtest.AssertRequiredSet(r, nil, &Config{})
+	if len(r.errors) != 0 {
+		t.Errorf("expected no errors, got %v", r.errors)
+	}
+}
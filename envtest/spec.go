@@ -0,0 +1,468 @@
+package envtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+// SpecDir is replaced in the values of SpecCase.Env with the directory holding the case's files.
+const SpecDir = "{dir}"
+
+// SpecCase describes one resolution scenario: the environment, the parser and the struct to populate.
+type SpecCase struct {
+	Name   string
+	Env    map[string]string  // Variables set for the case
+	Files  map[string]string  // Files created in a temporary directory, by name, e.g. for the `file` option
+	Parser func() *env.Parser // Parser to use, env.NewParser() when nil
+	Target func() interface{} // Pointer to a fresh struct to populate
+}
+
+// SpecDatabase is a nested struct of the spec cases, bound to different variables through prefixes.
+type SpecDatabase struct {
+	DbHost string `env:"name=HOST,default=localhost"`
+	DbPort int    `env:"name=PORT,default=5432"`
+}
+
+// SpecServer is a nested struct of the spec cases, embedded next to SpecDatabase.
+type SpecServer struct {
+	Host string `env:"name=HOST"`
+}
+
+// SpecCases returns the spec fixtures covering the tag options and their combinations: transform order,
+// defaults, separators, nesting and the options reading, masking or removing values. The golden output of
+// CheckSpec records the resolved struct and the resolution table of every case, so any change to resolution
+// semantics shows up as a diff. Forks can append their own cases to verify new features against the same
+// golden file. Add a case for every new option; existing cases must keep their golden output.
+func SpecCases() []SpecCase {
+	return []SpecCase{
+		{
+			Name: "name lookup order",
+			Env:  map[string]string{"SPEC_B": "b", "SPEC_C": "c", "Host": "field", "HOST": "upper"},
+			Target: func() interface{} {
+				return &struct {
+					Value string `env:"name=SPEC_A|SPEC_B|SPEC_C"`
+					Host  string `env:""`
+				}{}
+			},
+		},
+		{
+			Name: "exact",
+			Env:  map[string]string{"SPEC_REGION": "eu-west-1", "Fallback": "field", "FALLBACK": "upper"},
+			Target: func() interface{} {
+				return &struct {
+					Region   string `env:"name=SPEC_REGION,exact"`
+					Fallback string `env:"name=SPEC_FALLBACK,exact,default=none"`
+				}{}
+			},
+		},
+		{
+			Name: "transform order",
+			Env:  map[string]string{"SPEC_TRIM": "  MiXeD  ", "SPEC_NOTRIM": "  MiXeD  ", "SPEC_UPPER": " mixed "},
+			Target: func() interface{} {
+				return &struct {
+					Trim   string `env:"name=SPEC_TRIM,lower"`
+					NoTrim string `env:"name=SPEC_NOTRIM,notrim,lower"`
+					Upper  string `env:"name=SPEC_UPPER,upper,oneof=MIXED"`
+				}{}
+			},
+		},
+		{
+			Name: "defaults",
+			Env:  map[string]string{"SPEC_BLANK": "   ", "SPEC_SET": "set"},
+			Target: func() interface{} {
+				return &struct {
+					Unset string   `env:"name=SPEC_UNSET,default=fallback"`
+					Blank string   `env:"name=SPEC_BLANK,default=fallback"`
+					Set   string   `env:"name=SPEC_SET,default=fallback"`
+					Upper string   `env:"name=SPEC_UPPER_DEFAULT,default=low,upper"`
+					Port  int      `env:"name=SPEC_PORT,default=8080"`
+					List  []string `env:"name=SPEC_LIST,default=a|b"`
+				}{}
+			},
+		},
+		{
+			Name: "defaultenv",
+			Env:  map[string]string{"SPEC_PORT": "9090"},
+			Target: func() interface{} {
+				return &struct {
+					Port int    `env:"name=SPEC_APP_PORT,defaultenv=SPEC_PORT,default=8080"`
+					Host string `env:"name=SPEC_APP_HOST,defaultenv=SPEC_HOST,default=localhost"`
+				}{}
+			},
+		},
+		{
+			Name: "default_if",
+			Env:  map[string]string{"SPEC_ENV": "staging"},
+			Target: func() interface{} {
+				return &struct {
+					Env      string `env:"name=SPEC_ENV"`
+					Level    string `env:"name=SPEC_LOG_LEVEL,default_if=SPEC_ENV=production:warn|SPEC_ENV=staging:info,default=debug"`
+					Replicas int    `env:"name=SPEC_REPLICAS,default_if=SPEC_ENV=production:3,default=1"`
+				}{}
+			},
+		},
+		{
+			Name: "keep",
+			Env:  map[string]string{"SPEC_OVERRIDDEN": "env"},
+			Target: func() interface{} {
+				return &struct {
+					Kept       string `env:"name=SPEC_KEPT,keep,default=fallback"`
+					Replaced   string `env:"name=SPEC_REPLACED,default=fallback"`
+					Overridden string `env:"name=SPEC_OVERRIDDEN,keep"`
+				}{Kept: "existing", Replaced: "existing", Overridden: "existing"}
+			},
+		},
+		{
+			Name: "interpolate",
+			Env:  map[string]string{"SPEC_HOST": "db", "SPEC_NAME": "{{.Host}}-replica"},
+			Target: func() interface{} {
+				return &struct {
+					Host string `env:"name=SPEC_HOST"`
+					Port int    `env:"name=SPEC_PORT,default=5432"`
+					URL  string `env:"name=SPEC_URL,interpolate,default=postgres://{{.Host}}:{{.Port}}/app"`
+					Name string `env:"name=SPEC_NAME,interpolate"`
+				}{}
+			},
+		},
+		{
+			Name: "required",
+			Target: func() interface{} {
+				return &struct {
+					Value string `env:"name=SPEC_REQUIRED|SPEC_REQUIRED_ALT,required"`
+				}{}
+			},
+		},
+		{
+			Name: "default separators",
+			Env:  map[string]string{"SPEC_STRINGS": "a| b |c", "SPEC_INTS": "1|2|3"},
+			Target: func() interface{} {
+				return &struct {
+					Strings []string `env:"name=SPEC_STRINGS"`
+					Ints    []int    `env:"name=SPEC_INTS"`
+				}{}
+			},
+		},
+		{
+			Name: "custom separators",
+			Env:  map[string]string{"SPEC_STRINGS": "a,b;c"},
+			Parser: func() *env.Parser {
+				return env.NewParser().WithTagOptionSeparator(";").WithSliceValueSeparator(",")
+			},
+			Target: func() interface{} {
+				return &struct {
+					Strings []string `env:"name=SPEC_STRINGS;default=x,y"`
+				}{}
+			},
+		},
+		{
+			Name: "separator",
+			Env:  map[string]string{"SPEC_PATH": "/usr/bin:/bin", "SPEC_HOSTS": "a b"},
+			Target: func() interface{} {
+				return &struct {
+					Path  []string `env:"name=SPEC_PATH,separator=:"`
+					Hosts []string `env:"name=SPEC_HOSTS,separator= "`
+				}{}
+			},
+		},
+		{
+			Name: "quoted",
+			Env:  map[string]string{"SPEC_TAGS": `"a|b"|c\|d`},
+			Target: func() interface{} {
+				return &struct {
+					Tags []string `env:"name=SPEC_TAGS,quoted"`
+				}{}
+			},
+		},
+		{
+			Name: "unique and sorted",
+			Env:  map[string]string{"SPEC_TAGS": "b|a|b|c", "SPEC_PORTS": "443|80|8080"},
+			Target: func() interface{} {
+				return &struct {
+					Tags  []string `env:"name=SPEC_TAGS,unique=dedupe,sorted"`
+					Ports []int    `env:"name=SPEC_PORTS,unique,sorted"`
+				}{}
+			},
+		},
+		{
+			Name: "unique failure",
+			Env:  map[string]string{"SPEC_TAGS": "a|b|a"},
+			Target: func() interface{} {
+				return &struct {
+					Tags []string `env:"name=SPEC_TAGS,unique"`
+				}{}
+			},
+		},
+		{
+			Name: "name prefix",
+			Env:  map[string]string{"APP_SPEC_HOST": "prefixed", "SPEC_HOST": "plain"},
+			Parser: func() *env.Parser {
+				return env.NewParser().WithNamePrefix("APP_")
+			},
+			Target: func() interface{} {
+				return &struct {
+					Host string `env:"name=SPEC_HOST"`
+				}{}
+			},
+		},
+		{
+			Name: "nesting",
+			Env:  map[string]string{"DB_HOST": "db", "SERVER_HOST": "server", "HOST": "plain", "SPEC_LEVEL": "debug"},
+			Target: func() interface{} {
+				return &struct {
+					SpecDatabase `env:"prefix=DB_"`
+					SpecServer   `env:"prefix=SERVER_"`
+					Log          struct {
+						Level string `env:"name=SPEC_LEVEL"`
+					}
+				}{}
+			},
+		},
+		{
+			Name: "nested prefix",
+			Env:  map[string]string{"APP_PRIMARY_HOST": "primary", "APP_REPLICA_HOST": "replica"},
+			Parser: func() *env.Parser {
+				return env.NewParser().WithNamePrefix("APP_")
+			},
+			Target: func() interface{} {
+				return &struct {
+					Primary SpecDatabase `env:"prefix=PRIMARY_"`
+					Replica SpecDatabase `env:"prefix=REPLICA_"`
+				}{}
+			},
+		},
+		{
+			Name: "numbers and units",
+			Env:  map[string]string{"SPEC_SIZE": "512Mi", "SPEC_RATIO": "25%", "SPEC_TIMEOUT": "1m30s", "SPEC_LEVEL": "warn"},
+			Target: func() interface{} {
+				return &struct {
+					Size    int64         `env:"name=SPEC_SIZE,bytesize"`
+					Ratio   float64       `env:"name=SPEC_RATIO,percent"`
+					Timeout time.Duration `env:"name=SPEC_TIMEOUT,min=0"`
+					Level   int           `env:"name=SPEC_LEVEL,enum=debug:0|info:1|warn:2"`
+				}{}
+			},
+		},
+		{
+			Name: "booleans",
+			Env:  map[string]string{"SPEC_ON": "yes", "SPEC_OFF": "0", "SPEC_STD": "true"},
+			Target: func() interface{} {
+				return &struct {
+					On  bool `env:"name=SPEC_ON,truthy=yes|on,falsy=no|off"`
+					Off bool `env:"name=SPEC_OFF"`
+					Std bool `env:"name=SPEC_STD"`
+				}{}
+			},
+		},
+		{
+			Name: "json",
+			Env:  map[string]string{"SPEC_JSON": `{"a":"1","b":"2"}`},
+			Target: func() interface{} {
+				return &struct {
+					Labels map[string]string `env:"name=SPEC_JSON,json"`
+				}{}
+			},
+		},
+		{
+			Name: "expand",
+			Env:  map[string]string{"SPEC_BASE": "/srv", "SPEC_DATA": "${SPEC_BASE}/data"},
+			Target: func() interface{} {
+				return &struct {
+					Data  string `env:"name=SPEC_DATA,expand"`
+					Cache string `env:"name=SPEC_CACHE,default=$SPEC_BASE/cache,expand"`
+				}{}
+			},
+		},
+		{
+			Name:  "file",
+			Env:   map[string]string{"SPEC_PASSWORD_FILE": SpecDir + "/password"},
+			Files: map[string]string{"password": "s3cret\n"},
+			Target: func() interface{} {
+				return &struct {
+					Password string `env:"name=SPEC_PASSWORD_FILE,file"`
+				}{}
+			},
+		},
+		{
+			Name: "sensitive",
+			Env:  map[string]string{"SPEC_PASSWORD": "hunter2", "SPEC_USER": "admin"},
+			Target: func() interface{} {
+				return &struct {
+					User     string `env:"name=SPEC_USER"`
+					Password string `env:"name=SPEC_PASSWORD,sensitive"`
+				}{}
+			},
+		},
+		{
+			Name: "sensitive failure",
+			Env:  map[string]string{"SPEC_TOKEN": "short"},
+			Target: func() interface{} {
+				return &struct {
+					Token string `env:"name=SPEC_TOKEN,sensitive,len=8"`
+				}{}
+			},
+		},
+		{
+			Name: "encrypted",
+			Env:  map[string]string{"SPEC_SECRET": env.EncryptedValuePrefix + "c2VjcmV0"},
+			Parser: func() *env.Parser {
+				return env.NewParser().WithDecryptor(specDecrypt)
+			},
+			Target: func() interface{} {
+				return &struct {
+					Secret string `env:"name=SPEC_SECRET,encrypted"`
+				}{}
+			},
+		},
+		{
+			Name: "encrypted plaintext",
+			Env:  map[string]string{"SPEC_SECRET": "secret"},
+			Parser: func() *env.Parser {
+				return env.NewParser().WithDecryptor(specDecrypt)
+			},
+			Target: func() interface{} {
+				return &struct {
+					Secret string `env:"name=SPEC_SECRET,encrypted"`
+				}{}
+			},
+		},
+		{
+			Name: "unset",
+			Env:  map[string]string{"SPEC_TOKEN": "abc", "SPEC_USER": "admin"},
+			Target: func() interface{} {
+				return &struct {
+					User  string `env:"name=SPEC_USER"`
+					Token string `env:"name=SPEC_TOKEN,unset"`
+				}{}
+			},
+		},
+		{
+			Name: "validation failure",
+			Env:  map[string]string{"SPEC_MODE": "fast"},
+			Target: func() interface{} {
+				return &struct {
+					Mode string `env:"name=SPEC_MODE,oneof=safe|slow"`
+				}{}
+			},
+		},
+		{
+			Name: "xor conflict",
+			Env:  map[string]string{"SPEC_PASSWORD": "secret", "SPEC_PASSWORD_FILE": "/run/secret"},
+			Target: func() interface{} {
+				return &struct {
+					Password     string `env:"name=SPEC_PASSWORD,xor=password"`
+					PasswordFile string `env:"name=SPEC_PASSWORD_FILE,xor=password"`
+				}{}
+			},
+		},
+	}
+}
+
+// specDecrypt is the decryptor of the spec cases: the ciphertext is the plaintext.
+func specDecrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	return ciphertext, nil
+}
+
+// RunSpecCase resolves a spec case and formats the outcome for the golden file: the environment and files,
+// then the populated struct and its resolution table, or the error. The variables of the struct that the case
+// does not set are unset for the duration of the test, so the outcome does not depend on the environment the
+// tests run in. Variables removed from the environment while resolving are listed as unset.
+func RunSpecCase(t testing.TB, c SpecCase) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "=== %s\n", c.Name)
+	for _, name := range sortedKeys(c.Files) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(c.Files[name]), 0o600); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+		fmt.Fprintf(&buf, "file: %s=%q\n", name, c.Files[name])
+	}
+	p := env.NewParser()
+	if c.Parser != nil {
+		p = c.Parser()
+	}
+	target := c.Target()
+
+	// Start from a clean slate for the variables of the struct, e.g. USER or PATH read through field names
+	docs, err := p.Document(target)
+	if err != nil {
+		t.Fatalf("documenting %s: %v", c.Name, err)
+	}
+	for _, d := range docs {
+		for _, name := range d.Names {
+			if _, ok := c.Env[name]; !ok {
+				Unset(t, name)
+			}
+		}
+	}
+	keys := sortedKeys(c.Env)
+	vars := make(map[string]string, len(keys))
+	for _, k := range keys {
+		vars[k] = strings.ReplaceAll(c.Env[k], SpecDir, dir)
+		fmt.Fprintf(&buf, "env: %s=%q\n", k, c.Env[k])
+	}
+	Set(t, vars)
+
+	var table bytes.Buffer
+	if err := p.PrintTable(&table, target); err != nil {
+		fmt.Fprintf(&buf, "error: %s\n\n", strings.ReplaceAll(err.Error(), dir, SpecDir))
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, "result: %+v\n", target)
+	for _, k := range keys {
+		if _, ok := os.LookupEnv(k); !ok {
+			fmt.Fprintf(&buf, "unset: %s\n", k)
+		}
+	}
+	fmt.Fprintf(&buf, "%s\n", strings.ReplaceAll(table.String(), dir, SpecDir))
+	return buf.String()
+}
+
+// CheckSpec runs the spec cases and compares their outcome with the golden file, or rewrites the file first
+// when update is set.
+func CheckSpec(t *testing.T, cases []SpecCase, golden string, update bool) {
+	t.Helper()
+	var got bytes.Buffer
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got.WriteString(RunSpecCase(t, c))
+		})
+	}
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("creating the golden file directory: %v", err)
+		}
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", golden, err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading %s: %v (run with -update to create the golden file)", golden, err)
+	}
+	if got.String() != string(want) {
+		t.Errorf("resolution differs from %s (run with -update if the change is intended):\n--- got\n%s\n--- want\n%s", golden, got.String(), want)
+	}
+}
+
+// sortedKeys returns the keys of the map in ascending order, so the golden output is stable.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
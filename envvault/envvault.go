@@ -0,0 +1,206 @@
+// Package envvault reads values from the HashiCorp Vault KV version 2 secrets engine, per field from `vault://`
+// references or `vault=` tag options, or for all keys of a secret. It talks to the Vault HTTP API directly, so
+// it adds no dependency.
+package envvault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/igwtcode/go-env"
+)
+
+// Scheme is the scheme of Vault references, e.g., vault://secret/app/db#password.
+const Scheme = "vault"
+
+// Client reads KV version 2 secrets, authenticating with a token or with AppRole credentials.
+// It is safe for concurrent use.
+type Client struct {
+	Addr       string       // Address of the Vault server (e.g., https://vault.example.com:8200)
+	Token      string       // Token to authenticate with; obtained through AppRole login when empty
+	RoleID     string       // AppRole role ID
+	SecretID   string       // AppRole secret ID
+	Namespace  string       // Vault Enterprise namespace, if any
+	HTTPClient *http.Client // HTTP client to use (default: http.DefaultClient)
+
+	mu    sync.Mutex
+	token string // Token obtained through AppRole login
+}
+
+// NewTokenClient creates a client authenticating with a token.
+func NewTokenClient(addr, token string) *Client {
+	return &Client{Addr: addr, Token: token}
+}
+
+// NewAppRoleClient creates a client authenticating with AppRole credentials. It logs in on first use and again
+// when the token is rejected.
+func NewAppRoleClient(addr, roleID, secretID string) *Client {
+	return &Client{Addr: addr, RoleID: roleID, SecretID: secretID}
+}
+
+// Read returns the data of the latest version of a secret. The path starts with the mount of the secrets engine,
+// as with `vault kv get` (e.g., secret/app/db reads /v1/secret/data/app/db).
+func (c *Client) Read(ctx context.Context, path string) (map[string]interface{}, error) {
+	mount, rest, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("vault path %s has no mount", path)
+	}
+
+	var out struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/"+mount+"/data/"+rest, nil, &out); err != nil {
+		return nil, fmt.Errorf("reading vault secret %s: %w", path, err)
+	}
+	return out.Data.Data, nil
+}
+
+// Resolver returns a resolver for `vault://` references, to register with env.Parser.WithResolver. The
+// reference is the path of a secret followed by `#key` (e.g., vault://secret/app/db#password). String values
+// are used as is, other values in their JSON encoding.
+func (c *Client) Resolver() env.ResolverFunc {
+	return func(ctx context.Context, ref string) (string, error) {
+		path, key, ok := strings.Cut(ref, "#")
+		if !ok || key == "" {
+			return "", fmt.Errorf("vault reference %s has no #key", ref)
+		}
+		data, err := c.Read(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		val, ok := data[key]
+		if !ok {
+			return "", fmt.Errorf("vault secret %s has no key %s", path, key)
+		}
+		return format(val)
+	}
+}
+
+// Source reads a secret and returns a source exposing its keys as variables, in upper case (e.g., the key
+// db_password becomes DB_PASSWORD).
+func (c *Client) Source(ctx context.Context, path string) (env.Source, error) {
+	data, err := c.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	for key, val := range data {
+		s, err := format(val)
+		if err != nil {
+			return nil, err
+		}
+		vars[strings.ToUpper(key)] = s
+	}
+	return env.MapSource(vars), nil
+}
+
+// format returns the string form of a secret value.
+func format(val interface{}) (string, error) {
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(val)
+	return string(b), err
+}
+
+// errPermissionDenied is returned for requests rejected with status 403.
+var errPermissionDenied = errors.New("permission denied")
+
+// do sends an authenticated request, logging in again once when an AppRole token is rejected.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	token, err := c.authToken(ctx, false)
+	if err != nil {
+		return err
+	}
+	err = c.request(ctx, method, path, token, body, out)
+	if errors.Is(err, errPermissionDenied) && c.Token == "" {
+		if token, err = c.authToken(ctx, true); err != nil {
+			return err
+		}
+		err = c.request(ctx, method, path, token, body, out)
+	}
+	return err
+}
+
+// authToken returns the configured token, or logs in with AppRole when there is none yet or refresh is set.
+func (c *Client) authToken(ctx context.Context, refresh bool) (string, error) {
+	if c.Token != "" {
+		return c.Token, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && !refresh {
+		return c.token, nil
+	}
+	if c.RoleID == "" {
+		return "", errors.New("vault client has neither a token nor AppRole credentials")
+	}
+
+	var out struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": c.RoleID, "secret_id": c.SecretID}
+	if err := c.request(ctx, http.MethodPost, "/v1/auth/approle/login", "", body, &out); err != nil {
+		return "", fmt.Errorf("vault approle login: %w", err)
+	}
+	c.token = out.Auth.ClientToken
+	return c.token, nil
+}
+
+// request sends a request to the Vault API and decodes the JSON response into out.
+func (c *Client) request(ctx context.Context, method, path, token string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.Addr, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return errPermissionDenied
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if len(e.Errors) > 0 {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.Join(e.Errors, "; "))
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,147 @@
+package envvault_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+	"github.com/igwtcode/go-env/envvault"
+)
+
+// fakeVault serves the KV v2 secret secret/app/db and AppRole logins. Tokens issued by logins are accepted
+// until the server is told to expire them.
+type fakeVault struct {
+	logins  atomic.Int32
+	expired atomic.Bool
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		f.logins.Add(1)
+		f.expired.Store(false)
+		w.Write([]byte(`{"auth":{"client_token":"approle-token"}}`))
+	case "/v1/secret/data/app/db":
+		token := r.Header.Get("X-Vault-Token")
+		if token != "root" && (token != "approle-token" || f.expired.Load()) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"password":"s3cret","port":5432},"metadata":{"version":3}}}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+	}
+}
+
+func TestResolver(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD,vault=secret/app/db#password"`
+		Port     int    `env:"name=DB_PORT"`
+		User     string `env:"name=DB_USER,default=app"`
+	}
+
+	srv := httptest.NewServer(&fakeVault{})
+	defer srv.Close()
+	client := envvault.NewTokenClient(srv.URL, "root")
+	parser := env.NewParser().WithResolver(envvault.Scheme, client.Resolver())
+
+	os.Setenv("DB_PORT", "vault://secret/app/db#port")
+	defer os.Unsetenv("DB_PORT")
+	os.Unsetenv("DB_PASSWORD")
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Port != 5432 || cfg.User != "app" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	os.Setenv("DB_PASSWORD", "from-env")
+	defer os.Unsetenv("DB_PASSWORD")
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "from-env" {
+		t.Errorf("expected the variable to take precedence over the tag reference, got '%s'", cfg.Password)
+	}
+
+	tests := map[string]string{
+		"vault://secret/app/db":         "vault reference secret/app/db has no #key",
+		"vault://secret/app/db#user":    "vault secret secret/app/db has no key user",
+		"vault://secret/app/other#port": "reading vault secret secret/app/other: status 404",
+	}
+	for ref, want := range tests {
+		os.Setenv("DB_PORT", ref)
+		if err := parser.Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", ref, want, err)
+		}
+	}
+}
+
+func TestAppRoleLogin(t *testing.T) {
+	vault := &fakeVault{}
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+	client := envvault.NewAppRoleClient(srv.URL, "role", "secret")
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Read(ctx, "secret/app/db"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if n := vault.logins.Load(); n != 1 {
+		t.Errorf("expected the token to be reused, got %d logins", n)
+	}
+
+	vault.expired.Store(true)
+	if _, err := client.Read(ctx, "secret/app/db"); err != nil {
+		t.Fatalf("expected a new login after the token expired, got %v", err)
+	}
+	if n := vault.logins.Load(); n != 2 {
+		t.Errorf("expected 2 logins, got %d", n)
+	}
+
+	bad := envvault.NewAppRoleClient(srv.URL, "role", "wrong")
+	if _, err := bad.Read(ctx, "secret/app/db"); err == nil || !strings.Contains(err.Error(), "vault approle login: status 400: invalid role or secret ID") {
+		t.Errorf("expected a login error, got %v", err)
+	}
+}
+
+func TestSource(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=PASSWORD"`
+		Port     int    `env:"name=PORT"`
+	}
+
+	srv := httptest.NewServer(&fakeVault{})
+	defer srv.Close()
+	src, err := envvault.NewTokenClient(srv.URL, "root").Source(context.Background(), "secret/app/db")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var cfg Config
+	if err := env.NewParser().WithSources(src).Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Port != 5432 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
@@ -0,0 +1,103 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RequiredError is returned when a required field has no value.
+type RequiredError struct {
+	Field     string   // Dotted field path (e.g., Database.Port)
+	Names     []string // Environment variable names that were tried
+	Condition string   // Condition of the `required_if` option that made the field required, if any
+
+	root      string // Name of the top-level struct type, prepended to the field path in the message
+	separator string // Separator used to join the names in the message
+}
+
+func (e *RequiredError) Error() string {
+	names := strings.Join(e.Names, e.separator)
+	ref, want, hasWant := strings.Cut(e.Condition, "=")
+	switch {
+	case e.Condition == "":
+		return fmt.Sprintf("%s: environment variable %s is required but not set", describeField(e.root, e.Field, ""), names)
+	case hasWant:
+		return fmt.Sprintf("%s: environment variable %s is required when %s is %s", describeField(e.root, e.Field, ""), names, ref, want)
+	default:
+		return fmt.Sprintf("%s: environment variable %s is required when %s is set", describeField(e.root, e.Field, ""), names, ref)
+	}
+}
+
+// ParseError is returned when a value cannot be converted to the type of its field.
+type ParseError struct {
+	Field string   // Dotted field path (e.g., Database.Port)
+	Names []string // Environment variable names that were tried
+	Var   string   // Environment variable the value came from (empty for defaults)
+	Value string   // Offending value ("***" for sensitive fields and secret sources)
+	Err   error    // Underlying conversion error
+
+	root string // Name of the top-level struct type, prepended to the field path in the message
+}
+
+func (e *ParseError) Error() string {
+	return describeField(e.root, e.Field, e.Var) + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ValidationError is returned when a value does not pass a validation option (e.g., `min`, `oneof` or `regex`).
+type ValidationError struct {
+	Field string   // Dotted field path (e.g., Database.Port)
+	Names []string // Environment variable names that were tried
+	Var   string   // Environment variable the value came from (empty for defaults)
+	Value string   // Offending value ("***" for sensitive fields and secret sources)
+	Err   error    // Underlying validation error
+
+	root string // Name of the top-level struct type, prepended to the field path in the message
+}
+
+func (e *ValidationError) Error() string {
+	return describeField(e.root, e.Field, e.Var) + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// describeField names a field in error messages by its full path and, if known, the variable it was read
+// from (e.g., "Config.Database.Port (DB_PORT)").
+func describeField(root, fieldPath, name string) string {
+	path := root
+	if fieldPath != "" {
+		path = joinPath(root, fieldPath)
+	}
+	if name != "" {
+		return path + " (" + name + ")"
+	}
+	return path
+}
+
+// invalid marks an error as a validation failure. The field details are filled in by fieldError.
+func invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ValidationError{Err: err}
+}
+
+// fieldError turns an error decoding a field into a ParseError or ValidationError carrying the field's details.
+// For sensitive fields and values of secret sources, the value is masked in both the error and its message.
+func (p *Parser) fieldError(err error, st *decodeState, fieldPath string, names []string, name, raw, val string, sensitive bool) error {
+	var ve *ValidationError
+	isValidation := errors.As(err, &ve)
+	if isValidation {
+		err = ve.Err
+	}
+	if sensitive {
+		err = errors.New(redact(err.Error(), raw, val, p.SliceValueSeparator))
+		val = maskedValue
+	}
+	if isValidation {
+		return &ValidationError{Field: fieldPath, Names: names, Var: name, Value: val, Err: err, root: st.root}
+	}
+	return &ParseError{Field: fieldPath, Names: names, Var: name, Value: val, Err: err, root: st.root}
+}
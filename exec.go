@@ -0,0 +1,65 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ExecValuePrefix    = "exec:"         // Prefix marking a value as a command to run
+	DefaultExecTimeout = 5 * time.Second // Default timeout for exec values
+)
+
+// isExecValue reports whether the value should be resolved by running a command.
+// Exec values are only honored when the parser has a non-empty command allowlist.
+func (p *Parser) isExecValue(val string) bool {
+	return len(p.ExecAllowlist) > 0 && strings.HasPrefix(val, ExecValuePrefix)
+}
+
+// runExecValue runs the command described by an `exec:` value and returns its stdout.
+//
+// The command is split on whitespace and executed directly (no shell is involved).
+// The command name must be present in the parser's allowlist, and the command is
+// killed if it does not finish within the configured timeout or when the context is done.
+func (p *Parser) runExecValue(ctx context.Context, val string) (string, error) {
+	args := strings.Fields(strings.TrimPrefix(val, ExecValuePrefix))
+	if len(args) == 0 {
+		return "", errors.New("exec value has no command")
+	}
+	if !slices.Contains(p.ExecAllowlist, args[0]) {
+		return "", fmt.Errorf("exec command '%s' is not allowed", args[0])
+	}
+
+	timeout := p.ExecTimeout
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runCtx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("exec command '%s' was canceled: %w", args[0], err)
+		}
+		if runCtx.Err() != nil {
+			return "", fmt.Errorf("exec command '%s' timed out after %v", args[0], timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("exec command '%s' failed: %v: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("exec command '%s' failed: %v", args[0], err)
+	}
+
+	// Drop the trailing newline most CLI tools print after the value
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
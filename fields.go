@@ -0,0 +1,60 @@
+package env
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// fieldInfo describes a field of a struct type that is read from environment variables.
+type fieldInfo struct {
+	field      reflect.StructField
+	index      []int             // Index sequence of the field for reflect.Value.FieldByIndex
+	path       string            // Dotted path of the field within the top-level struct
+	prefix     string            // Prefix of the enclosing structs, added to the names
+	tagOptions map[string]string // Parsed options of the `env` tag
+	names      []string          // Candidate variable names (shared, must not be modified)
+}
+
+// names returns the names of the field's variables in lookup order, including the replacement
+// of a deprecated variable.
+func (p *Parser) names(f fieldInfo) []string {
+	names := slices.Clone(f.names)
+	if dep := f.tagOptions[topt.DEPRECATED]; dep != "" {
+		names = append([]string{p.NamePrefix + f.prefix + dep}, names...)
+	}
+	return names
+}
+
+// fields lists the tagged fields of a struct type, recursing into nested and embedded structs the same way
+// Unmarshal does.
+func (p *Parser) fields(t reflect.Type) []fieldInfo {
+	var out []fieldInfo
+	p.collectFields(t, nil, "", "", &out)
+	return out
+}
+
+// collectFields appends the tagged fields of a struct type to out.
+func (p *Parser) collectFields(t reflect.Type, index []int, prefix string, path string, out *[]fieldInfo) {
+	meta := p.structMeta(t, prefix)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || meta[i].skip {
+			continue
+		}
+		tagOk, tagOptions := meta[i].tagOk, meta[i].tagOptions
+		_, isJSON := tagOptions[topt.JSON]
+		fieldIndex := append(append([]int{}, index...), i)
+		fieldPath := joinPath(path, field.Name)
+
+		if field.Type.Kind() == reflect.Struct && !p.isLeafType(field.Type) && !isJSON {
+			p.collectFields(field.Type, fieldIndex, p.nestedPrefix(field, tagOptions, prefix), fieldPath, out)
+			continue
+		}
+
+		if tagOk {
+			*out = append(*out, fieldInfo{field: field, index: fieldIndex, path: fieldPath, prefix: prefix, tagOptions: tagOptions, names: meta[i].names})
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NoGroupLimit is the maximum of a group constraint without an upper limit.
+const NoGroupLimit = -1
+
+// GroupConstraint limits how many fields of a group may be set. A zero Max allows no set fields at all;
+// use NoGroupLimit for no upper limit.
+//
+// Constraints are configured on the parser rather than in tags, because the `min` and `max` tag options
+// already validate the value of the field they appear on.
+type GroupConstraint struct {
+	Min int // Minimum number of set fields
+	Max int // Maximum number of set fields (NoGroupLimit or any negative number means no limit)
+}
+
+// addToGroups records a set variable for each of the given groups.
+func (st *decodeState) addToGroups(groups []string, envName string) {
+	if st.groups == nil {
+		st.groups = map[string][]string{}
+	}
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		st.groups[g] = append(st.groups[g], envName)
+	}
+}
+
+// checkGroups validates the number of set fields of every constrained group.
+func (p *Parser) checkGroups(st *decodeState) error {
+	names := make([]string, 0, len(p.Groups))
+	for name := range p.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c := p.Groups[name]
+		set := st.groups[name]
+		if len(set) < c.Min || (c.Max >= 0 && len(set) > c.Max) {
+			return fmt.Errorf("group '%s' requires %s to be set, got %d%s", name, c.describe(), len(set), formatSetNames(set))
+		}
+	}
+	return nil
+}
+
+// addToXor records a set variable for each of the given mutually exclusive groups.
+func (st *decodeState) addToXor(groups []string, envName string) {
+	if st.xor == nil {
+		st.xor = map[string][]string{}
+	}
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		st.xor[g] = append(st.xor[g], envName)
+	}
+}
+
+// checkXor validates that at most one field of every mutually exclusive group is set.
+func (st *decodeState) checkXor() error {
+	names := make([]string, 0, len(st.xor))
+	for name := range st.xor {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if set := st.xor[name]; len(set) > 1 {
+			return fmt.Errorf("only one of %s may be set (xor group '%s')", strings.Join(set, ", "), name)
+		}
+	}
+	return nil
+}
+
+// describe returns a human-readable description of the constraint.
+func (c GroupConstraint) describe() string {
+	switch {
+	case c.Max < 0:
+		return fmt.Sprintf("at least %d field(s)", c.Min)
+	case c.Max == 0:
+		return "no fields"
+	case c.Min == c.Max:
+		return fmt.Sprintf("exactly %d field(s)", c.Min)
+	case c.Min <= 0:
+		return fmt.Sprintf("at most %d field(s)", c.Max)
+	default:
+		return fmt.Sprintf("between %d and %d fields", c.Min, c.Max)
+	}
+}
+
+// formatSetNames formats the names of the set variables for error messages.
+func formatSetNames(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,46 @@
+package env
+
+import (
+	"context"
+	"reflect"
+)
+
+// FieldInfo describes the field being populated, for hooks.
+type FieldInfo struct {
+	Context context.Context // Context of the Unmarshal call
+	Path    string          // Dotted field path (e.g., Database.Port)
+	Type    reflect.Type    // Type of the field
+	Tag     string          // Value of the field's `env` tag
+	Names   []string        // Environment variable names that were tried
+	Var     string          // Variable the value came from (empty for defaults)
+	Source  string          // Source of the value (SourceEnv, SourceDefault or SourceNone)
+}
+
+// FieldHook observes or rewrites the value of a field before it is validated and converted
+// (e.g., to strip `secret://` prefixes or resolve aliases). The returned value replaces the raw value.
+type FieldHook func(f FieldInfo, raw string) (string, error)
+
+// PostSetHook observes the value of a field once it is set. Returning an error fails the field.
+type PostSetHook func(f FieldInfo, value interface{}) error
+
+// WithFieldHook configures a hook called with the resolved value of every field before it is validated and
+// converted. Values returned by the hook go through the same checks as values read from variables.
+func (p *Parser) WithFieldHook(hook FieldHook) *Parser {
+	p.FieldHook = hook
+	return p
+}
+
+// WithPostSetHook configures a hook called with the value of every field once it is set.
+func (p *Parser) WithPostSetHook(hook PostSetHook) *Parser {
+	p.PostSetHook = hook
+	return p
+}
+
+// hookInfo returns the description of a field passed to hooks.
+func (st *decodeState) hookInfo(field reflect.StructField, fieldPath string, names []string, rec fieldRecord) FieldInfo {
+	info := FieldInfo{Context: st.ctx, Path: fieldPath, Type: field.Type, Tag: field.Tag.Get("env"), Names: names, Source: rec.Source}
+	if rec.Source == SourceEnv {
+		info.Var = rec.Name
+	}
+	return info
+}
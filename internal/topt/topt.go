@@ -1,17 +1,70 @@
 package topt
 
 const (
-	NAME     = "name"
-	REQUIRED = "required"
-	DEFAULT  = "default"
-	NOTRIM   = "notrim"
-	LOWER    = "lower"
-	UPPER    = "upper"
-	MIN      = "min"
-	MAX      = "max"
+	NAME        = "name"
+	REQUIRED    = "required"
+	OPTIONAL    = "optional"
+	DEFAULT     = "default"
+	DEFAULTENV  = "defaultenv"
+	DEFAULT_IF  = "default_if"
+	KEEP        = "keep"
+	EXACT       = "exact"
+	NOTRIM      = "notrim"
+	LOWER       = "lower"
+	UPPER       = "upper"
+	MIN         = "min"
+	MAX         = "max"
+	GT          = "gt"
+	GTE         = "gte"
+	LT          = "lt"
+	LTE         = "lte"
+	MULTIPLEOF  = "multipleof"
+	NONZERO     = "nonzero"
+	LAYOUT      = "layout"
+	ENUM        = "enum"
+	PREFIX      = "prefix"
+	BYTESIZE    = "bytesize"
+	GROUP       = "group"
+	XOR         = "xor"
+	PERCENT     = "percent"
+	FLATTEN     = "flatten"
+	REGEX       = "regex"
+	TRUTHY      = "truthy"
+	FALSY       = "falsy"
+	ONEOF       = "oneof"
+	LEN         = "len"
+	ALPHANUM    = "alphanum"
+	ASCII       = "ascii"
+	JSON        = "json"
+	EXPAND      = "expand"
+	FILE        = "file"
+	UNSET       = "unset"
+	SENSITIVE   = "sensitive"
+	DEPRECATED  = "deprecated"
+	NOTEMPTY    = "notempty"
+	TRIM        = "trim"
+	TRIMPREFIX  = "trimprefix"
+	TRIMSUFFIX  = "trimsuffix"
+	QUOTED      = "quoted"
+	UNIQUE      = "unique"
+	SORTED      = "sorted"
+	ENCRYPTED   = "encrypted"
+	INTERPOLATE = "interpolate"
+	SEPARATOR   = "separator"
 
-	V_AWS_REGION      = "v_aws_region"
-	V_AWS_ACCOUNT_ID  = "v_aws_account_id"
-	V_AWS_ROLE_ARN    = "v_aws_role_arn"
-	V_AWS_BUCKET_NAME = "v_aws_bucket_name"
+	REQUIRED_IF = "required_if"
+
+	V_MAC      = "v_mac"
+	V_DATETIME = "v_datetime"
+	V_JSON     = "v_json"
+	V_BASE64   = "v_base64"
+
+	V_AWS_REGION        = "v_aws_region"
+	V_AWS_ACCOUNT_ID    = "v_aws_account_id"
+	V_AWS_ROLE_ARN      = "v_aws_role_arn"
+	V_AWS_BUCKET_NAME   = "v_aws_bucket_name"
+	V_AWS_SQS_QUEUE_URL = "v_aws_sqs_queue_url"
+	V_AWS_SNS_TOPIC_ARN = "v_aws_sns_topic_arn"
+	V_AWS_LAMBDA        = "v_aws_lambda"
+	V_AWS_KMS_KEY       = "v_aws_kms_key"
 )
@@ -0,0 +1,144 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// fieldRefPattern matches references to other fields in values, e.g., {{.Database.Host}}.
+var fieldRefPattern = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_.]*)\s*\}\}`)
+
+// Interpolation states of fields with references.
+const (
+	refPending = iota
+	refResolving
+	refDone
+	refFailed
+)
+
+// pendingField is a field whose value references other fields, populated once the other fields are.
+type pendingField struct {
+	field  reflect.StructField
+	value  reflect.Value
+	meta   fieldMeta
+	prefix string
+	path   string // Path of the enclosing struct
+}
+
+// WithInterpolation configures whether values and defaults can reference other fields by path, e.g.,
+// `default={{.Host}}:{{.Port}}`. Referencing fields are populated after all other fields, in dependency order,
+// and reference cycles fail. The `interpolate` option enables this for a single field.
+func (p *Parser) WithInterpolation(enabled bool) *Parser {
+	p.Interpolate = enabled
+	return p
+}
+
+// interpolates reports whether the value of a field references other fields that must be substituted.
+func (p *Parser) interpolates(tagOptions map[string]string, val string) bool {
+	_, interpolate := tagOptions[topt.INTERPOLATE]
+	return (interpolate || p.Interpolate) && fieldRefPattern.MatchString(val)
+}
+
+// deferField records a field with references, to populate once all other fields are.
+func (st *decodeState) deferField(pf pendingField) {
+	if st.pending == nil {
+		st.pending = map[string]pendingField{}
+	}
+	fieldPath := joinPath(pf.path, pf.field.Name)
+	st.pending[fieldPath] = pf
+	st.pendingOrder = append(st.pendingOrder, fieldPath)
+}
+
+// resolvePending populates the fields with references, in the order they were found.
+func (p *Parser) resolvePending(st *decodeState) error {
+	st.refStates = map[string]int{}
+	for _, fieldPath := range st.pendingOrder {
+		if err := st.fail(p.resolvePendingField(st, fieldPath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePendingField populates a field with references, after the pending fields it references.
+func (p *Parser) resolvePendingField(st *decodeState, fieldPath string) error {
+	switch st.refStates[fieldPath] {
+	case refDone, refFailed:
+		return nil
+	case refResolving:
+		cycle := append(slices.Clone(st.refChain), fieldPath)
+		for i := range cycle {
+			cycle[i] = joinPath(st.root, cycle[i])
+		}
+		return fmt.Errorf("field reference cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	st.refStates[fieldPath] = refResolving
+	st.refChain = append(st.refChain, fieldPath)
+	pf := st.pending[fieldPath]
+	err := p.unmarshalField(pf.field, pf.value, pf.meta, pf.prefix, pf.path, st)
+	st.refChain = st.refChain[:len(st.refChain)-1]
+	st.refStates[fieldPath] = refDone
+	if err != nil {
+		st.refStates[fieldPath] = refFailed
+	}
+	return err
+}
+
+// interpolate substitutes the references to other fields in a value with their values, formatted the way
+// Marshal writes them. Errors of referenced fields are returned as is, other errors describe the field.
+func (p *Parser) interpolate(st *decodeState, fieldPath, envName, val string) (string, error) {
+	var err error
+	out := fieldRefPattern.ReplaceAllStringFunc(val, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		refPath := fieldRefPattern.FindStringSubmatch(ref)[1]
+		if _, ok := st.pending[refPath]; ok {
+			if st.refStates[refPath] == refFailed {
+				err = fmt.Errorf("%s: referenced field %s is invalid", describeField(st.root, fieldPath, envName), refPath)
+				return ""
+			}
+			if err = p.resolvePendingField(st, refPath); err != nil {
+				return ""
+			}
+		}
+
+		v := st.rootValue
+		for _, name := range strings.Split(refPath, ".") {
+			if v.Kind() != reflect.Struct {
+				v = reflect.Value{}
+				break
+			}
+			v = v.FieldByName(name)
+			if !v.IsValid() {
+				break
+			}
+		}
+		if !v.IsValid() {
+			err = fmt.Errorf("%s: unknown field %s referenced", describeField(st.root, fieldPath, envName), refPath)
+			return ""
+		}
+		s, ferr := p.formatValue(v, st.refOptions(p, refPath))
+		if ferr != nil {
+			err = fmt.Errorf("%s: formatting field %s: %w", describeField(st.root, fieldPath, envName), refPath, ferr)
+		}
+		return s
+	})
+	return out, err
+}
+
+// refOptions returns the tag options of the field at the path, used to format its value.
+func (st *decodeState) refOptions(p *Parser, fieldPath string) map[string]string {
+	for _, f := range p.fields(st.typ) {
+		if f.path == fieldPath {
+			return f.tagOptions
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package env
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithLogger configures a structured logger receiving an event for each field (at debug level, or error level
+// when the field fails) and for each Unmarshal call (at info level, or error level when it fails). Events carry
+// the field path, the variable name, the source of the value, the duration and the error. Values are not logged.
+func (p *Parser) WithLogger(logger *slog.Logger) *Parser {
+	p.Logger = logger
+	return p
+}
+
+// logField logs the outcome of a field. Successful fields are described by their last record.
+func (p *Parser) logField(st *decodeState, fieldPath string, d time.Duration, err error) {
+	if p.Logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("struct", st.root), slog.String("field", fieldPath)}
+	if err != nil {
+		attrs = append(attrs, slog.Duration("duration", d), slog.Any("error", err))
+		p.Logger.LogAttrs(st.ctx, slog.LevelError, "env field failed", attrs...)
+		return
+	}
+	if n := len(st.records); n > 0 && st.records[n-1].Path == fieldPath {
+		rec := st.records[n-1]
+		if rec.Source == SourceEnv {
+			attrs = append(attrs, slog.String("env", rec.Name))
+		}
+		attrs = append(attrs, slog.String("source", rec.Source))
+	}
+	attrs = append(attrs, slog.Duration("duration", d))
+	p.Logger.LogAttrs(st.ctx, slog.LevelDebug, "env field resolved", attrs...)
+}
+
+// logDecode logs the outcome of an Unmarshal call.
+func (p *Parser) logDecode(st *decodeState, d time.Duration, err error) {
+	attrs := []slog.Attr{slog.String("struct", st.root), slog.Int("fields", len(st.records)), slog.Duration("duration", d)}
+	if err != nil {
+		p.Logger.LogAttrs(st.ctx, slog.LevelError, "env config failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	p.Logger.LogAttrs(st.ctx, slog.LevelInfo, "env config loaded", attrs...)
+}
@@ -0,0 +1,180 @@
+package env
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// Marshal turns a populated struct back into environment variables, using the same tags as Unmarshal.
+//
+// Each field is written under its first name (including the name prefix and the prefixes of nested structs),
+// or under the replacement name of a deprecated one. Slices are joined with the slice value separator, with
+// elements quoted as needed for fields with the `quoted` option, and fields with the `json` option are encoded
+// as JSON. Sensitive fields, fields read from files or decrypted (`file` and `encrypted` options) and nil pointers
+// are skipped, as their values are secrets that would not read back.
+func (p *Parser) Marshal(envStruct interface{}) (map[string]string, error) {
+	v := reflect.Indirect(reflect.ValueOf(envStruct))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct or a pointer to a struct, got %T", envStruct)
+	}
+	out := map[string]string{}
+	for _, f := range p.fields(v.Type()) {
+		fieldValue := v.FieldByIndex(f.index)
+		_, sensitive := f.tagOptions[topt.SENSITIVE]
+		_, file := f.tagOptions[topt.FILE]
+		_, encrypted := f.tagOptions[topt.ENCRYPTED]
+		if sensitive || file || encrypted {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+			continue
+		}
+
+		name := p.names(f)[0]
+		var val string
+		var err error
+		if _, isJSON := f.tagOptions[topt.JSON]; isJSON {
+			var b []byte
+			b, err = json.Marshal(fieldValue.Interface())
+			val = string(b)
+		} else {
+			val, err = p.formatValue(fieldValue, f.tagOptions)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", describeField(v.Type().Name(), f.path, name), err)
+		}
+		out[name] = val
+	}
+	return out, nil
+}
+
+// formatValue formats a field value so that Unmarshal reads it back with the same tag options.
+func (p *Parser) formatValue(v reflect.Value, tagOptions map[string]string) (string, error) {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	case timeType:
+		layout := tagOptions[topt.LAYOUT]
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Interface().(time.Time).Format(layout), nil
+	}
+
+	// Use the type's own text encoding, or the string form of types decoded by a converter
+	if !v.CanAddr() {
+		addressable := reflect.New(v.Type()).Elem()
+		addressable.Set(v)
+		v = addressable
+	}
+	if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	if s, ok := v.Addr().Interface().(fmt.Stringer); ok && p.converter(v.Type()) != nil {
+		return s.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num := strconv.FormatInt(v.Int(), 10)
+		if enum, ok := tagOptions[topt.ENUM]; ok {
+			return p.enumName(num, enum)
+		}
+		return num, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num := strconv.FormatUint(v.Uint(), 10)
+		if enum, ok := tagOptions[topt.ENUM]; ok {
+			return p.enumName(num, enum)
+		}
+		return num, nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		// Fractions are read back from percentages, unless the percent sign is only stripped
+		if mode, ok := tagOptions[topt.PERCENT]; ok && mode == "" {
+			return formatPercent(f, v.Type().Bits()), nil
+		}
+		return strconv.FormatFloat(f, 'f', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", nil
+		}
+		return p.formatValue(v.Elem(), tagOptions)
+	case reflect.Slice:
+		separator := p.sliceSeparator(tagOptions)
+		_, quoted := tagOptions[topt.QUOTED]
+		values := make([]string, v.Len())
+		for i := range values {
+			val, err := p.formatValue(v.Index(i), tagOptions)
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case quoted:
+				val = quoteElement(val, separator)
+			case strings.Contains(val, separator):
+				return "", fmt.Errorf("element %q contains the separator %q: use the quoted option", val, separator)
+			}
+			values[i] = val
+		}
+		return strings.Join(values, separator), nil
+	default:
+		return "", fmt.Errorf("unsupported field type %s: use the json option or a type implementing encoding.TextMarshaler", v.Type())
+	}
+}
+
+// quoteElement quotes a slice element containing the separator, quotes or backslashes, escaping the latter
+// two, so that the `quoted` option reads it back as a single element.
+func quoteElement(val, separator string) string {
+	if !strings.Contains(val, separator) && !strings.ContainsAny(val, `"\`) {
+		return val
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(val) + `"`
+}
+
+// formatPercent formats a fraction as a percentage by moving the decimal point of its shortest representation,
+// so it reads back to the same value without artifacts of a multiplication (e.g., 7% rather than
+// 7.000000000000001% for 0.07).
+func formatPercent(f float64, bits int) string {
+	s := strconv.FormatFloat(f, 'e', -1, bits)
+	mant, exp, ok := strings.Cut(s, "e")
+	if !ok {
+		return s + "%" // NaN and infinities
+	}
+	e, _ := strconv.Atoi(exp)
+	sign, digits := "", strings.Replace(mant, ".", "", 1)
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	switch point := e + 3; {
+	case point <= 0:
+		digits = "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		digits += strings.Repeat("0", point-len(digits))
+	default:
+		digits = digits[:point] + "." + digits[point:]
+	}
+	return sign + digits + "%"
+}
+
+// enumName maps a number back to its name using the enum option (e.g., "debug:0|info:1|warn:2").
+func (p *Parser) enumName(num string, enum string) (string, error) {
+	for _, entry := range strings.Split(enum, p.SliceValueSeparator) {
+		name, n, ok := strings.Cut(entry, ":")
+		if ok && strings.TrimSpace(n) == num {
+			return strings.TrimSpace(name), nil
+		}
+	}
+	return "", fmt.Errorf("value %s has no name in the enum option", num)
+}
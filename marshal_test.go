@@ -0,0 +1,227 @@
+package env_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+func TestMarshal(t *testing.T) {
+	type Database struct {
+		Host     string `env:"name=HOST"`
+		Password string `env:"name=PASSWORD,sensitive"`
+	}
+	type Config struct {
+		Name     string            `env:"name=APP_NAME|NAME"`
+		Port     int               `env:"name=PORT"`
+		Debug    bool              `env:"name=DEBUG"`
+		Ratio    float64           `env:"name=RATIO,percent"`
+		Level    int               `env:"name=LEVEL,enum=debug:0|info:1|warn:2"`
+		Timeout  time.Duration     `env:"name=TIMEOUT"`
+		Started  time.Time         `env:"name=STARTED,layout=2006-01-02"`
+		Hosts    []string          `env:"name=HOSTS"`
+		Endpoint url.URL           `env:"name=ENDPOINT"`
+		Labels   map[string]string `env:"name=LABELS,json"`
+		Old      string            `env:"name=OLD_NAME,deprecated=NEW_NAME"`
+		Limit    *int              `env:"name=LIMIT"`
+		Ignored  string
+		Database Database `env:"prefix=DB_"`
+	}
+
+	endpoint, _ := url.Parse("https://example.com/api")
+	cfg := Config{
+		Name:     "app",
+		Port:     8080,
+		Debug:    true,
+		Ratio:    0.25,
+		Level:    2,
+		Timeout:  90 * time.Second,
+		Started:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Hosts:    []string{"a", "b"},
+		Endpoint: *endpoint,
+		Labels:   map[string]string{"team": "core"},
+		Old:      "value",
+		Ignored:  "ignored",
+		Database: Database{Host: "db", Password: "secret"},
+	}
+
+	got, err := env.NewParser().WithNamePrefix("X_").Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]string{
+		"X_APP_NAME": "app",
+		"X_PORT":     "8080",
+		"X_DEBUG":    "true",
+		"X_RATIO":    "25%",
+		"X_LEVEL":    "warn",
+		"X_TIMEOUT":  "1m30s",
+		"X_STARTED":  "2024-05-01",
+		"X_HOSTS":    "a|b",
+		"X_ENDPOINT": "https://example.com/api",
+		"X_LABELS":   `{"team":"core"}`,
+		"X_NEW_NAME": "value",
+		"X_DB_HOST":  "db",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d variables, got %d: %v", len(want), len(got), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	type Config struct {
+		Host    string        `env:"name=RT_HOST"`
+		Ports   []int         `env:"name=RT_PORTS"`
+		Timeout time.Duration `env:"name=RT_TIMEOUT"`
+		Ratio   float32       `env:"name=RT_RATIO"`
+	}
+
+	in := Config{Host: "example.com", Ports: []int{80, 443}, Timeout: time.Second, Ratio: 0.1}
+	vars, err := env.NewParser().Marshal(in)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for k, v := range vars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	var out Config
+	if err := env.NewParser().Unmarshal(&out); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out.Host != in.Host || len(out.Ports) != 2 || out.Ports[1] != 443 || out.Timeout != in.Timeout || out.Ratio != in.Ratio {
+		t.Errorf("expected %+v, got %+v", in, out)
+	}
+}
+
+func TestMarshalRoundTripQuotedElements(t *testing.T) {
+	type Config struct {
+		Labels []string `env:"name=LABELS,quoted"`
+		Hosts  []string `env:"name=HOSTS"`
+	}
+
+	cfg := Config{Labels: []string{"a|b", `say "hi"`, `back\slash`, "plain"}}
+	parser := env.NewParser()
+	vars, err := parser.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var got Config
+	if err := parser.UnmarshalFromMap(vars, &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(got.Labels, cfg.Labels) {
+		t.Errorf("expected %q to read back as %q, got %q", vars["LABELS"], cfg.Labels, got.Labels)
+	}
+
+	_, err = parser.Marshal(&Config{Hosts: []string{"a|b"}})
+	if err == nil || !strings.Contains(err.Error(), "use the quoted option") {
+		t.Errorf("expected an error for an element containing the separator, got %v", err)
+	}
+}
+
+func TestMarshalRoundTripPercent(t *testing.T) {
+	type Config struct {
+		Ratio   float64   `env:"name=RATIO,percent"`
+		Small   float32   `env:"name=SMALL,percent"`
+		Weights []float64 `env:"name=WEIGHTS,percent"`
+	}
+
+	cfg := Config{Ratio: 0.07, Small: 0.29, Weights: []float64{0.1, 0.015, 0.333, 1.0 / 3, -0.00001, 12}}
+	parser := env.NewParser()
+	vars, err := parser.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if vars["RATIO"] != "7%" || vars["SMALL"] != "29%" || vars["WEIGHTS"] != "10%|1.5%|33.3%|33.33333333333333%|-0.001%|1200%" {
+		t.Errorf("expected percentages without rounding artifacts, got %v", vars)
+	}
+
+	var got Config
+	if err := parser.UnmarshalFromMap(vars, &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Ratio != cfg.Ratio || got.Small != cfg.Small || !slices.Equal(got.Weights, cfg.Weights) {
+		t.Errorf("expected %+v to read back, got %+v", cfg, got)
+	}
+}
+
+func TestMarshalSkipsSecretSources(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=PASS_FILE,file"`
+		Token    string `env:"name=TOKEN,encrypted"`
+		Host     string `env:"name=HOST"`
+	}
+
+	secret := filepath.Join(t.TempDir(), "pass")
+	if err := os.WriteFile(secret, []byte("hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// reverse stands in for a KMS decryption
+	reverse := func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+		out := make([]byte, len(ciphertext))
+		for i, b := range ciphertext {
+			out[len(ciphertext)-1-i] = b
+		}
+		return out, nil
+	}
+	src := map[string]string{
+		"PASS_FILE": secret,
+		"TOKEN":     "enc:" + base64.StdEncoding.EncodeToString([]byte("terces")),
+		"HOST":      "db",
+	}
+
+	parser := env.NewParser().WithDecryptor(reverse)
+	var cfg Config
+	if err := parser.UnmarshalFromMap(src, &cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "hunter2" || cfg.Token != "secret" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	vars, err := parser.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(vars) != 1 || vars["HOST"] != "db" {
+		t.Errorf("expected only HOST to be written, got %v", vars)
+	}
+
+	var out Config
+	if err := parser.UnmarshalFromMap(vars, &out); err != nil {
+		t.Fatalf("expected the output to read back, got %v", err)
+	}
+	if out.Host != "db" || out.Password != "" || out.Token != "" {
+		t.Errorf("unexpected config: %+v", out)
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	cfg := struct {
+		Labels map[string]string `env:"name=LABELS"`
+	}{Labels: map[string]string{"a": "b"}}
+
+	_, err := env.NewParser().Marshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Labels (LABELS): unsupported field type") {
+		t.Errorf("expected an unsupported type error, got %v", err)
+	}
+	if _, err := env.NewParser().Marshal("text"); err == nil {
+		t.Errorf("expected an error for a non-struct value")
+	}
+}
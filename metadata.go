@@ -0,0 +1,75 @@
+package env
+
+import (
+	"reflect"
+	"sync"
+)
+
+// fieldMeta holds what the parser derives from the tag of a struct field.
+type fieldMeta struct {
+	tagOk      bool              // Whether the field has an `env` tag
+	skip       bool              // Whether the field is tagged with "-"
+	tagOptions map[string]string // Parsed tag options (shared, must not be modified)
+	names      []string          // Candidate variable names (shared, must not be modified)
+}
+
+// metaKey identifies the metadata of a struct type under a prefix and the parser settings the
+// tag options and names depend on.
+type metaKey struct {
+	t                   reflect.Type
+	prefix              string
+	tagOptionSeparator  string
+	sliceValueSeparator string
+	namePrefix          string
+	snakeCaseNames      bool
+	exactNameMatch      bool
+	explicitNamesOnly   bool
+	caarlosTags         bool
+	envconfigTags       bool
+}
+
+// metaCache holds the field metadata of struct types, so tags are parsed and names are derived only once
+// per type instead of on every Unmarshal call.
+var metaCache sync.Map
+
+// structMeta returns the metadata of the fields of a struct type whose variables carry the given prefix.
+func (p *Parser) structMeta(t reflect.Type, prefix string) []fieldMeta {
+	key := metaKey{
+		t:                   t,
+		prefix:              prefix,
+		tagOptionSeparator:  p.TagOptionSeparator,
+		sliceValueSeparator: p.SliceValueSeparator,
+		namePrefix:          p.NamePrefix,
+		snakeCaseNames:      p.SnakeCaseNames,
+		exactNameMatch:      p.ExactNameMatch,
+		explicitNamesOnly:   p.ExplicitNamesOnly,
+		caarlosTags:         p.CaarlosTags,
+		envconfigTags:       p.EnvconfigTags,
+	}
+	if cached, ok := metaCache.Load(key); ok {
+		return cached.([]fieldMeta)
+	}
+
+	fields := make([]fieldMeta, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+		if p.CaarlosTags {
+			fields[i] = p.caarlosMeta(field, prefix)
+			continue
+		}
+		if p.EnvconfigTags {
+			fields[i] = p.envconfigMeta(field, prefix)
+			continue
+		}
+		tagVal, tagOk := field.Tag.Lookup("env")
+		fields[i] = fieldMeta{tagOk: tagOk, skip: tagVal == "-"}
+		if tagOk && !fields[i].skip {
+			fields[i].tagOptions = p.parseTag(tagVal)
+			fields[i].names = getEnvNames(field.Name, fields[i].tagOptions, p, prefix)
+			// Fields without names are not bound, but their options still apply to nested structs
+			fields[i].tagOk = len(fields[i].names) > 0
+		}
+	}
+	metaCache.Store(key, fields)
+	return fields
+}
@@ -0,0 +1,83 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Candidate is an environment variable that is set for a field.
+type Candidate struct {
+	Name  string // Environment variable name
+	Value string // Environment variable value
+}
+
+// ResolvePolicy chooses the candidate to use when several names of a field are set.
+// Candidates are given in lookup order and only contain set variables: those with a non-empty value, or with
+// EmptyIsSet, also those set to an empty value (which then conflict with non-empty ones). The candidates that
+// are not chosen are reported by Resolve and PrintTable.
+type ResolvePolicy func(candidates []Candidate) (Candidate, error)
+
+// FirstWins uses the first set variable in lookup order.
+func FirstWins(candidates []Candidate) (Candidate, error) {
+	return candidates[0], nil
+}
+
+// LastWins uses the last set variable in lookup order, e.g., so a later override name takes precedence.
+func LastWins(candidates []Candidate) (Candidate, error) {
+	return candidates[len(candidates)-1], nil
+}
+
+// ErrorOnConflict returns an error when set variables have different values.
+func ErrorOnConflict(candidates []Candidate) (Candidate, error) {
+	if hasConflict(candidates) {
+		return Candidate{}, fmt.Errorf("environment variables %s are set with different values", candidateNames(candidates))
+	}
+	return candidates[0], nil
+}
+
+// PreferLongestPrefix uses the set variable with the longest, most specific name (e.g., AWS_DEFAULT_REGION
+// over AWS_REGION). Names of the same length are chosen in lookup order.
+func PreferLongestPrefix(candidates []Candidate) (Candidate, error) {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if len(c.Name) > len(best.Name) {
+			best = c
+		}
+	}
+	return best, nil
+}
+
+// resolveCandidates applies the parser's resolve policy and warns about conflicting values.
+func (p *Parser) resolveCandidates(candidates []Candidate) (Candidate, error) {
+	policy := p.ResolvePolicy
+	if policy == nil {
+		policy = FirstWins
+	}
+	chosen, err := policy(candidates)
+	if err != nil {
+		return Candidate{}, err
+	}
+	if hasConflict(candidates) {
+		p.warn("environment variables %s are set with different values, using %s", candidateNames(candidates), chosen.Name)
+	}
+	return chosen, nil
+}
+
+// hasConflict reports whether the candidates have different values.
+func hasConflict(candidates []Candidate) bool {
+	for _, c := range candidates[1:] {
+		if c.Value != candidates[0].Value {
+			return true
+		}
+	}
+	return false
+}
+
+// candidateNames joins the names of the candidates for messages.
+func candidateNames(candidates []Candidate) string {
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,30 @@
+package env
+
+import "strings"
+
+// RewritePrefix returns a copy of an environ-style list (KEY=VALUE entries, e.g., os.Environ())
+// where variables whose names start with oldPrefix are renamed to start with newPrefix instead.
+//
+// Variables that already exist under the new name are kept and take precedence over the renamed ones.
+func RewritePrefix(environ []string, oldPrefix, newPrefix string) []string {
+	existing := map[string]bool{}
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		existing[key] = true
+	}
+
+	out := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, val, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, oldPrefix) {
+			out = append(out, kv)
+			continue
+		}
+		renamed := newPrefix + strings.TrimPrefix(key, oldPrefix)
+		if existing[renamed] && renamed != key {
+			continue
+		}
+		out = append(out, renamed+"="+val)
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Provider fetches values from a remote backend (e.g., SSM, Vault or Consul). It reports whether the key exists,
+// and an error when the backend cannot be reached.
+type Provider interface {
+	Fetch(ctx context.Context, key string) (string, bool, error)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, key string) (string, bool, error)
+
+// Fetch calls f.
+func (f ProviderFunc) Fetch(ctx context.Context, key string) (string, bool, error) {
+	return f(ctx, key)
+}
+
+// ResolverProvider adapts a resolver, such as those of the envssm, envsecrets and envvault packages, to a
+// Provider, e.g., to cache it with NewCachingProvider. Every resolved reference counts as existing.
+func ResolverProvider(fn ResolverFunc) Provider {
+	return ProviderFunc(func(ctx context.Context, key string) (string, bool, error) {
+		val, err := fn(ctx, key)
+		if err != nil {
+			return "", false, err
+		}
+		return val, true, nil
+	})
+}
+
+// WithProvider resolves values of the form `scheme://key` by fetching the key from the provider, like
+// WithResolver. Keys the provider does not have fail the field.
+func (p *Parser) WithProvider(scheme string, prov Provider) *Parser {
+	return p.WithResolver(scheme, func(ctx context.Context, key string) (string, error) {
+		val, ok, err := prov.Fetch(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("%s not found", key)
+		}
+		return val, nil
+	})
+}
+
+// cacheEntry is a value fetched by a caching provider.
+type cacheEntry struct {
+	val     string
+	ok      bool
+	expires time.Time
+}
+
+// fetchCall is a fetch in flight, shared by concurrent callers of the same key.
+type fetchCall struct {
+	done  chan struct{}
+	entry cacheEntry
+	err   error
+}
+
+// cachingProvider caches the values of another provider.
+type cachingProvider struct {
+	next Provider
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*fetchCall
+}
+
+// NewCachingProvider wraps a provider so each key is fetched at most once per ttl, and concurrent fetches of
+// the same key share a single request to the backend. Missing keys are cached too; errors are not, so the next
+// fetch tries again. It is safe for concurrent use.
+func NewCachingProvider(next Provider, ttl time.Duration) Provider {
+	return &cachingProvider{next: next, ttl: ttl, entries: map[string]cacheEntry{}, inflight: map[string]*fetchCall{}}
+}
+
+// Fetch returns the cached value of the key, or fetches it from the wrapped provider.
+func (c *cachingProvider) Fetch(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && time.Now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.val, e.ok, nil
+	}
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &fetchCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		go c.fetch(context.WithoutCancel(ctx), key, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.entry.val, call.entry.ok, call.err
+	case <-ctx.Done():
+		return "", false, ctx.Err()
+	}
+}
+
+// fetch runs a fetch in flight and stores its result. The context of the first caller is not canceled with it,
+// so a caller giving up does not fail the other callers waiting for the same key.
+func (c *cachingProvider) fetch(ctx context.Context, key string, call *fetchCall) {
+	val, ok, err := c.next.Fetch(ctx, key)
+	call.entry, call.err = cacheEntry{val: val, ok: ok, expires: time.Now().Add(c.ttl)}, err
+
+	c.mu.Lock()
+	if err == nil {
+		c.entries[key] = call.entry
+	}
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,111 @@
+package env_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+func TestCachingProvider(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	backend := env.ProviderFunc(func(ctx context.Context, key string) (string, bool, error) {
+		fetches.Add(1)
+		<-release
+		if key == "missing" {
+			return "", false, nil
+		}
+		return "value of " + key, true, nil
+	})
+	cache := env.NewCachingProvider(backend, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if val, ok, err := cache.Fetch(context.Background(), "db"); err != nil || !ok || val != "value of db" {
+				t.Errorf("unexpected result: %q, %v, %v", val, ok, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected concurrent fetches to share one request, got %d", n)
+	}
+
+	cache.Fetch(context.Background(), "db")
+	cache.Fetch(context.Background(), "missing")
+	if _, ok, _ := cache.Fetch(context.Background(), "missing"); ok {
+		t.Errorf("expected the missing key to be reported as missing")
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("expected cached values and missing keys to be reused, got %d fetches", n)
+	}
+}
+
+func TestCachingProviderTTLAndErrors(t *testing.T) {
+	var fetches atomic.Int32
+	fail := true
+	backend := env.ProviderFunc(func(ctx context.Context, key string) (string, bool, error) {
+		fetches.Add(1)
+		if fail {
+			return "", false, errors.New("backend unavailable")
+		}
+		return "v", true, nil
+	})
+	cache := env.NewCachingProvider(backend, 20*time.Millisecond)
+
+	if _, _, err := cache.Fetch(context.Background(), "k"); err == nil {
+		t.Fatal("expected the backend error")
+	}
+	fail = false
+	if _, ok, err := cache.Fetch(context.Background(), "k"); err != nil || !ok {
+		t.Fatalf("expected errors not to be cached, got %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	cache.Fetch(context.Background(), "k")
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("expected the value to be fetched again after the TTL, got %d fetches", n)
+	}
+}
+
+func TestWithProvider(t *testing.T) {
+	type Config struct {
+		Password string `env:"name=DB_PASSWORD"`
+	}
+
+	backend := env.ProviderFunc(func(ctx context.Context, key string) (string, bool, error) {
+		if key == "app/db" {
+			return "s3cret", true, nil
+		}
+		return "", false, nil
+	})
+	parser := env.NewParser().WithProvider("consul", env.NewCachingProvider(backend, time.Minute))
+
+	os.Setenv("DB_PASSWORD", "consul://app/db")
+	defer os.Unsetenv("DB_PASSWORD")
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Password != "s3cret" {
+		t.Errorf("expected Password to be 's3cret', got '%s'", cfg.Password)
+	}
+
+	os.Setenv("DB_PASSWORD", "consul://app/other")
+	err := parser.Unmarshal(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "resolving consul reference: app/other not found") {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
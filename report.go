@@ -0,0 +1,137 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Sources of resolved field values.
+const (
+	SourceEnv      = "env"      // Value read from an environment variable
+	SourceDefault  = "default"  // Value taken from the `default` option
+	SourceNone     = "none"     // No value was found
+	SourceExisting = "existing" // Value already present in the struct was kept
+)
+
+// maskedValue replaces values that must not be displayed.
+const maskedValue = "***"
+
+// fieldRecord describes how the value of a field was resolved.
+type fieldRecord struct {
+	Path   string // Dotted field path (e.g., Database.Port)
+	Name   string // Environment variable the value came from, or the first candidate name
+	Value  string // Final string value before conversion
+	Source string // Source of the value (SourceEnv, SourceDefault or SourceNone)
+	Masked bool   // Whether the value must be masked when displayed
+
+	Replacement string   // Name to use instead, when the value came from a deprecated variable
+	Ignored     []string // Other set variables of the field, whose values lost to the chosen one
+}
+
+// Resolution describes how the value of a field was resolved, as reported by Resolve.
+type Resolution struct {
+	Field       string `json:"field"`                 // Dotted field path (e.g., Database.Port)
+	Var         string `json:"var,omitempty"`         // Environment variable the value came from (empty unless Source is SourceEnv)
+	Value       string `json:"value"`                 // Final string value before conversion ("***" for sensitive fields and `exec:` values)
+	Source      string `json:"source"`                // Source of the value (SourceEnv, SourceDefault, SourceExisting or SourceNone)
+	Default     bool   `json:"default"`               // Whether the default was used
+	Replacement string `json:"replacement,omitempty"` // Name to use instead, when the value came from a deprecated variable
+	Ignored     string `json:"ignored,omitempty"`     // Other set variables of the field not used under the resolve policy, comma-separated
+}
+
+// displayValue returns the value to display for the record.
+func (r fieldRecord) displayValue() string {
+	if r.Masked && r.Value != "" {
+		return maskedValue
+	}
+	return r.Value
+}
+
+// redact masks the raw and final values of a sensitive field, and their slice elements, in the message.
+func redact(msg, raw, val, separator string) string {
+	secrets := []string{raw, val}
+	for _, v := range []string{raw, val} {
+		for _, e := range strings.Split(v, separator) {
+			secrets = append(secrets, strings.TrimSpace(e))
+		}
+	}
+	// Replace longer values first, so parts of them are not left behind
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	for _, s := range secrets {
+		if s != "" {
+			msg = strings.ReplaceAll(msg, s, maskedValue)
+		}
+	}
+	return msg
+}
+
+// joinPath appends a field name to a dotted field path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// PrintTable populates the struct like Unmarshal and writes a column-aligned table describing each field:
+// its variable, value (masked for secrets), source, and whether the default was used.
+// It is meant for `--show-config` style commands.
+func (p *Parser) PrintTable(w io.Writer, envStruct interface{}) error {
+	st := &decodeState{}
+	if err := p.decode(envStruct, st); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVARIABLE\tVALUE\tSOURCE\tDEFAULT")
+	for _, r := range st.records {
+		def := "no"
+		if r.Source == SourceDefault {
+			def = "yes"
+		}
+		name := r.Name
+		if r.Replacement != "" {
+			name += " (deprecated, use " + r.Replacement + ")"
+		}
+		if len(r.Ignored) > 0 {
+			name += " (ignored " + strings.Join(r.Ignored, ", ") + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Path, name, r.displayValue(), r.Source, def)
+	}
+	return tw.Flush()
+}
+
+// Resolve populates the struct like Unmarshal and reports, for each field, which variable the value came from,
+// whether the default was used, and the final value (masked for secrets). It helps to debug which of several
+// sources or names a value was taken from.
+func (p *Parser) Resolve(envStruct interface{}) ([]Resolution, error) {
+	st := &decodeState{}
+	if err := p.decode(envStruct, st); err != nil {
+		return nil, err
+	}
+
+	res := make([]Resolution, 0, len(st.records))
+	for _, r := range st.records {
+		rs := Resolution{Field: r.Path, Value: r.displayValue(), Source: r.Source, Default: r.Source == SourceDefault, Replacement: r.Replacement, Ignored: strings.Join(r.Ignored, ", ")}
+		if r.Source == SourceEnv {
+			rs.Var = r.Name
+		}
+		res = append(res, rs)
+	}
+	return res, nil
+}
+
+// MarshalResolvedJSON populates the struct like Resolve and returns the resolutions of its fields as a JSON array,
+// e.g., for a `/debug/config` endpoint. Values of sensitive fields and `exec:` values are masked.
+func (p *Parser) MarshalResolvedJSON(envStruct interface{}) ([]byte, error) {
+	res, err := p.Resolve(envStruct)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(res)
+}
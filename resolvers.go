@@ -0,0 +1,50 @@
+package env
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// ResolverFunc resolves a reference value such as `ssm:///app/db_password` to the actual value. It receives
+// the reference without the scheme and `://` (e.g., /app/db_password).
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// WithResolver resolves values of the form `scheme://ref` with fn, e.g., secrets kept in a remote store.
+// References are resolved after `expand` and `exec:` values, and resolved values are masked in reports.
+//
+// Fields can also name a reference in their tag with the scheme as option, e.g., `vault=secret/app/db#password`.
+// It is used like a default when no variable is set, taking precedence over the `default` option.
+func (p *Parser) WithResolver(scheme string, fn ResolverFunc) *Parser {
+	if p.Resolvers == nil {
+		p.Resolvers = map[string]ResolverFunc{}
+	}
+	p.Resolvers[scheme] = fn
+	return p
+}
+
+// resolver returns the resolver and the reference of a value with a registered scheme, if any.
+func (p *Parser) resolver(val string) (string, ResolverFunc, string) {
+	scheme, ref, ok := strings.Cut(val, "://")
+	if !ok {
+		return "", nil, ""
+	}
+	fn := p.Resolvers[scheme]
+	return scheme, fn, ref
+}
+
+// tagReference returns the reference named in the tag options with the scheme of a registered resolver, e.g.,
+// vault://secret/app/db#password for `vault=secret/app/db#password`, or an empty string.
+func (p *Parser) tagReference(tagOptions map[string]string) string {
+	schemes := make([]string, 0, len(p.Resolvers))
+	for scheme := range p.Resolvers {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	for _, scheme := range schemes {
+		if ref := tagOptions[strings.ToLower(scheme)]; ref != "" {
+			return scheme + "://" + ref
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// normalizeSlice applies the `unique` and `sorted` options to a decoded slice.
+// With the "dedupe" mode, `unique` removes repeated elements instead of rejecting them.
+func normalizeSlice(s reflect.Value, tagOptions map[string]string) (reflect.Value, error) {
+	if mode, ok := tagOptions[topt.UNIQUE]; ok {
+		if mode != "" && mode != "dedupe" {
+			return s, fmt.Errorf("invalid unique value: %s. Must be empty or dedupe", mode)
+		}
+		seen := map[interface{}]bool{}
+		out := reflect.MakeSlice(s.Type(), 0, s.Len())
+		for i := 0; i < s.Len(); i++ {
+			elem := s.Index(i)
+			key := elemKey(elem)
+			if seen[key] {
+				if mode == "dedupe" {
+					continue
+				}
+				return s, fmt.Errorf("duplicate value in list: %v", key)
+			}
+			seen[key] = true
+			out = reflect.Append(out, elem)
+		}
+		s = out
+	}
+
+	if _, ok := tagOptions[topt.SORTED]; ok {
+		less, err := elemLess(s)
+		if err != nil {
+			return s, err
+		}
+		sort.SliceStable(s.Interface(), less)
+	}
+	return s, nil
+}
+
+// elemKey returns a comparable key for a slice element, dereferencing pointers so equal values match.
+func elemKey(elem reflect.Value) interface{} {
+	for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+		elem = elem.Elem()
+	}
+	if elem.Type().Comparable() {
+		return elem.Interface()
+	}
+	return fmt.Sprint(elem.Interface())
+}
+
+// elemLess returns the ordering function for the elements of the slice.
+func elemLess(s reflect.Value) (func(i, j int) bool, error) {
+	switch s.Type().Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(i, j int) bool { return s.Index(i).Int() < s.Index(j).Int() }, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(i, j int) bool { return s.Index(i).Uint() < s.Index(j).Uint() }, nil
+	case reflect.Float32, reflect.Float64:
+		return func(i, j int) bool { return s.Index(i).Float() < s.Index(j).Float() }, nil
+	case reflect.String:
+		return func(i, j int) bool { return s.Index(i).String() < s.Index(j).String() }, nil
+	}
+	return nil, fmt.Errorf("sorted only applies to slices of strings or numbers, got %s", s.Type())
+}
@@ -0,0 +1,114 @@
+package env
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source provides variable values by name, e.g., the process environment, a map or command-line flags.
+type Source interface {
+	Lookup(key string) (string, bool)
+}
+
+// SourceFunc adapts a lookup function to a Source.
+type SourceFunc func(key string) (string, bool)
+
+// Lookup calls f.
+func (f SourceFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+// EnvSource returns a Source reading the process environment.
+func EnvSource() Source {
+	return SourceFunc(os.LookupEnv)
+}
+
+// MapSource returns a Source reading the key/value pairs of m, e.g., programmatic defaults or a parsed file.
+func MapSource(m map[string]string) Source {
+	return SourceFunc(func(key string) (string, bool) {
+		val, ok := m[key]
+		return val, ok
+	})
+}
+
+// DotenvSource returns a Source reading the variables of dotenv files, later files overriding earlier ones.
+// Missing files are skipped, as with WithDotenv.
+func DotenvSource(files ...string) (Source, error) {
+	vals, err := loadDotenvFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return MapSource(vals), nil
+}
+
+// DirSource returns a Source reading a directory of files, as mounted from Kubernetes ConfigMaps and Secrets:
+// each file name is a variable name and the file content, without trailing newlines, its value. Hidden entries
+// (such as the ..data links of projected volumes) and subdirectories are ignored, and a missing directory yields
+// an empty source. The files are read once. Put it after EnvSource in WithSources so real variables take
+// precedence.
+func DirSource(dir string) (Source, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return MapSource(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	vals := map[string]string{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		// Follow symbolic links, which projected volumes use for every file
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		vals[e.Name()] = strings.TrimRight(string(content), "\r\n")
+	}
+	return MapSource(vals), nil
+}
+
+// FlagSource returns a Source reading the flags of fs that were set on the command line. A variable name is
+// matched to a flag by removing the prefix and converting it to lower case with dashes, e.g., APP_DB_HOST to
+// db-host for the prefix APP_. Flags left at their default values are not reported as set.
+func FlagSource(fs *flag.FlagSet, prefix string) Source {
+	return SourceFunc(func(key string) (string, bool) {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			return "", false
+		}
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+		var val string
+		var set bool
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				val, set = f.Value.String(), true
+			}
+		})
+		return val, set
+	})
+}
+
+// WithSources reads variables from the sources in order, taking the value of the first source that has it,
+// e.g., WithSources(FlagSource(fs, "APP_"), EnvSource(), dotenv) for flags overriding the environment overriding
+// a file. The `default` options apply when no source has a value. It replaces the lookup function of WithLookup.
+func (p *Parser) WithSources(sources ...Source) *Parser {
+	return p.WithLookup(func(key string) (string, bool) {
+		for _, s := range sources {
+			if val, ok := s.Lookup(key); ok {
+				return val, ok
+			}
+		}
+		return "", false
+	})
+}
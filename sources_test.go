@@ -0,0 +1,109 @@
+package env_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/igwtcode/go-env"
+)
+
+func TestWithSources(t *testing.T) {
+	type Config struct {
+		Host    string `env:"name=APP_HOST"`
+		Port    int    `env:"name=APP_PORT"`
+		Debug   bool   `env:"name=APP_DEBUG,default=false"`
+		Level   string `env:"name=APP_LOG_LEVEL,default=info"`
+		Region  string `env:"name=APP_REGION"`
+		Timeout string `env:"name=APP_TIMEOUT"`
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, ".env")
+	if err := os.WriteFile(file, []byte("APP_HOST=file\nAPP_PORT=1000\nAPP_REGION=eu-west-1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dotenv, err := env.DotenvSource(file)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("host", "flag-default", "")
+	fs.String("log-level", "warn", "")
+	fs.Bool("debug", false, "")
+	if err := fs.Parse([]string{"--debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("APP_HOST", "env")
+	os.Setenv("APP_PORT", "2000")
+	defer os.Unsetenv("APP_HOST")
+	defer os.Unsetenv("APP_PORT")
+
+	defaults := env.MapSource(map[string]string{"APP_TIMEOUT": "5s", "APP_REGION": "us-east-1"})
+	parser := env.NewParser().WithSources(env.FlagSource(fs, "APP_"), env.EnvSource(), dotenv, defaults)
+
+	var cfg Config
+	if err := parser.Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "env" || cfg.Port != 2000 {
+		t.Errorf("expected the environment to override the file and unset flags, got %+v", cfg)
+	}
+	if !cfg.Debug {
+		t.Errorf("expected the flag to override the default, got %+v", cfg)
+	}
+	if cfg.Level != "info" {
+		t.Errorf("expected flag defaults to be ignored, got '%s'", cfg.Level)
+	}
+	if cfg.Region != "eu-west-1" || cfg.Timeout != "5s" {
+		t.Errorf("expected the file to override the map source, got %+v", cfg)
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	type Config struct {
+		Host     string `env:"name=DB_HOST"`
+		Password string `env:"name=DB_PASSWORD"`
+		Mode     string `env:"name=MODE,default=safe"`
+	}
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..data")
+	if err := os.Mkdir(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"DB_HOST": "db\n", "MODE": "fast"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(data, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := env.DirSource(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	os.Setenv("MODE", "env")
+	defer os.Unsetenv("MODE")
+
+	var cfg Config
+	if err := env.NewParser().WithSources(env.EnvSource(), src).Unmarshal(&cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Host != "db" || cfg.Password != "s3cret" || cfg.Mode != "env" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if _, err := env.DirSource(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("expected a missing directory to be skipped, got %v", err)
+	}
+}
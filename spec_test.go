@@ -0,0 +1,17 @@
+package env_test
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/igwtcode/go-env/envtest"
+)
+
+// update rewrites the golden file from the current behavior: go test -run TestSpec -update
+var update = flag.Bool("update", false, "update golden files")
+
+// TestSpec checks the resolution semantics against the golden output of the spec fixtures in envtest.
+func TestSpec(t *testing.T) {
+	envtest.CheckSpec(t, envtest.SpecCases(), filepath.Join("testdata", "spec.golden"), *update)
+}
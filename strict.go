@@ -0,0 +1,109 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// checkUnknown fails when strict mode is enabled and variables with the name prefix are not read by any field.
+// Variables referenced by `defaultenv`, `expand` and conditions count as read.
+func (p *Parser) checkUnknown(st *decodeState) error {
+	if !p.StrictUnknown || p.NamePrefix == "" {
+		return nil
+	}
+	if p.Lookup != nil && p.source == nil {
+		return errors.New("unknown variables cannot be listed from a custom lookup function or sources; use the process environment or UnmarshalFromMap")
+	}
+
+	known := map[string]bool{}
+	for name := range st.referenced {
+		known[name] = true
+	}
+	for _, f := range p.fields(st.typ) {
+		for _, name := range p.names(f) {
+			known[name] = true
+			if p.FileVariants {
+				known[name+FileVariantSuffix] = true
+			}
+		}
+		for _, cond := range p.conditions(f.tagOptions) {
+			ref, _, _ := strings.Cut(cond, "=")
+			known[p.NamePrefix+strings.TrimSpace(ref)] = true
+			known[strings.TrimSpace(ref)] = true
+		}
+	}
+
+	var unknown []string
+	for _, name := range p.environNames() {
+		if strings.HasPrefix(name, p.NamePrefix) && !known[name] && !slices.Contains(unknown, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	return fmt.Errorf("unknown environment variables with prefix %s: %s", p.NamePrefix, strings.Join(unknown, ", "))
+}
+
+// checkDuplicates fails when duplicate checks are enabled and two fields read the same variable.
+func (p *Parser) checkDuplicates(st *decodeState) error {
+	if !p.StrictDuplicates {
+		return nil
+	}
+
+	var errs []error
+	owners := map[string]string{}
+	for _, f := range p.fields(st.typ) {
+		for _, name := range p.boundNames(f) {
+			if owner, ok := owners[name]; ok {
+				errs = append(errs, fmt.Errorf("%s: variable %s is already read by %s", describeField(st.root, f.path, ""), name, describeField(st.root, owner, "")))
+				continue
+			}
+			owners[name] = f.path
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// boundNames returns the names a field is bound to on purpose: those of the `name` option, or else the first
+// name derived from the field name. The other fallback names are left out, as they often overlap by design.
+func (p *Parser) boundNames(f fieldInfo) []string {
+	name := f.tagOptions[topt.NAME]
+	if name == "" {
+		return f.names[:1]
+	}
+	var names []string
+	for _, n := range strings.Split(name, p.SliceValueSeparator) {
+		if n = p.NamePrefix + f.prefix + n; !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// environNames returns the names of all variables that can be looked up: those of the map source, or else
+// the process environment, and those of the dotenv files. Variables of custom lookup functions cannot be listed.
+func (p *Parser) environNames() []string {
+	var names []string
+	switch {
+	case p.source != nil:
+		for name := range p.source {
+			names = append(names, name)
+		}
+	case p.Lookup == nil:
+		for _, kv := range os.Environ() {
+			name, _, _ := strings.Cut(kv, "=")
+			names = append(names, name)
+		}
+	}
+	for name := range p.dotenv {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,98 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow represents a daily time window, such as a maintenance window or quiet hours.
+//
+// Start and End are offsets from midnight in the window's location. A window whose end is
+// before its start wraps around midnight (e.g., 22:00-06:00). A window whose start equals its
+// end covers the whole day.
+type TimeWindow struct {
+	Start    time.Duration  // Offset from midnight when the window opens
+	End      time.Duration  // Offset from midnight when the window closes
+	Location *time.Location // Location the offsets are relative to (default: UTC)
+}
+
+// ParseTimeWindow parses a time window in the format "HH:MM-HH:MM", optionally followed by a space and an
+// IANA time zone name (e.g., "22:00-06:00 Europe/Berlin"). Seconds may be given as "HH:MM:SS".
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return TimeWindow{}, fmt.Errorf("invalid time window: %q. Expected format: HH:MM-HH:MM [Zone]", s)
+	}
+
+	start, end, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window: %q. Expected format: HH:MM-HH:MM [Zone]", s)
+	}
+
+	w := TimeWindow{Location: time.UTC}
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window start: %w", err)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window end: %w", err)
+	}
+
+	if len(fields) == 2 {
+		if w.Location, err = time.LoadLocation(fields[1]); err != nil {
+			return TimeWindow{}, fmt.Errorf("invalid time window zone: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// parseClock parses a clock time ("HH:MM" or "HH:MM:SS") into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	layout := "15:04"
+	if strings.Count(s, ":") == 2 {
+		layout = "15:04:05"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock time: %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+}
+
+// Contains reports whether the given instant falls within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return offset >= w.Start && offset < w.End
+	default:
+		return offset >= w.Start || offset < w.End
+	}
+}
+
+// String formats the window in the same format accepted by ParseTimeWindow.
+func (w TimeWindow) String() string {
+	s := formatClock(w.Start) + "-" + formatClock(w.End)
+	if w.Location != nil && w.Location != time.UTC {
+		s += " " + w.Location.String()
+	}
+	return s
+}
+
+// formatClock formats an offset from midnight as "HH:MM", or "HH:MM:SS" when it has seconds.
+func formatClock(d time.Duration) string {
+	h, m, sec := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	if sec != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%02d:%02d", h, m)
+}
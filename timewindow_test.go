@@ -0,0 +1,77 @@
+package env_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/igwtcode/go-env"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	w, err := env.ParseTimeWindow("09:30-17:00")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if w.Start != 9*time.Hour+30*time.Minute {
+		t.Errorf("expected Start to be 9h30m, got %v", w.Start)
+	}
+	if w.End != 17*time.Hour {
+		t.Errorf("expected End to be 17h, got %v", w.End)
+	}
+	if w.Location != time.UTC {
+		t.Errorf("expected Location to be UTC, got %v", w.Location)
+	}
+}
+
+func TestParseTimeWindowWithZone(t *testing.T) {
+	w, err := env.ParseTimeWindow("22:00-06:00 Europe/Berlin")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if w.Location.String() != "Europe/Berlin" {
+		t.Errorf("expected Location to be 'Europe/Berlin', got %v", w.Location)
+	}
+	if w.String() != "22:00-06:00 Europe/Berlin" {
+		t.Errorf("expected String to round-trip, got %v", w.String())
+	}
+}
+
+func TestParseTimeWindowInvalid(t *testing.T) {
+	for _, s := range []string{"", "22:00", "25:00-06:00", "22:00-06:00 Nowhere/City", "22:00-06:00 UTC extra"} {
+		if _, err := env.ParseTimeWindow(s); err == nil {
+			t.Errorf("expected an error for %q, got none", s)
+		}
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	day := env.TimeWindow{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC}
+	night := env.TimeWindow{Start: 22 * time.Hour, End: 6 * time.Hour, Location: time.UTC}
+
+	at := func(h int) time.Time { return time.Date(2024, 5, 1, h, 0, 0, 0, time.UTC) }
+
+	if !day.Contains(at(12)) || day.Contains(at(17)) || day.Contains(at(8)) {
+		t.Errorf("unexpected result for day window %v", day)
+	}
+	if !night.Contains(at(23)) || !night.Contains(at(2)) || night.Contains(at(12)) {
+		t.Errorf("unexpected result for night window %v", night)
+	}
+}
+
+func TestTimeWindowContainsInZone(t *testing.T) {
+	w, err := env.ParseTimeWindow("22:00-06:00 Europe/Berlin")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// 21:30 UTC is 23:30 in Berlin during summer time
+	if !w.Contains(time.Date(2024, 7, 1, 21, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected window to contain 21:30 UTC")
+	}
+	// 05:30 UTC is 07:30 in Berlin during summer time
+	if w.Contains(time.Date(2024, 7, 1, 5, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected window not to contain 05:30 UTC")
+	}
+}
@@ -0,0 +1,42 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WithTrace writes a log of how each field is resolved to w: the candidate names and the one that matched, the
+// defaults and transformations applied, the final value (masked for secrets), and whether the field was set.
+// It is meant for debugging the resolution order, not for production logs.
+func (p *Parser) WithTrace(w io.Writer) *Parser {
+	p.Trace = w
+	return p
+}
+
+// tracef writes a line about the field to the trace writer, if any.
+func (p *Parser) tracef(st *decodeState, fieldPath, format string, args ...interface{}) {
+	if p.Trace == nil {
+		return
+	}
+	fmt.Fprintf(p.Trace, "%s: %s\n", describeField(st.root, fieldPath, ""), fmt.Sprintf(format, args...))
+}
+
+// traceLookup traces the candidate names of a field and the one that matched.
+func (p *Parser) traceLookup(st *decodeState, fieldPath string, names []string, matched string) {
+	if matched == "" {
+		matched = "none set"
+	} else {
+		matched = "matched " + matched
+	}
+	p.tracef(st, fieldPath, "candidates %s; %s", strings.Join(names, ", "), matched)
+}
+
+// traceValue returns the value of the record for traces, quoted or masked.
+func (r fieldRecord) traceValue() string {
+	if r.Masked && r.Value != "" {
+		return maskedValue
+	}
+	return strconv.Quote(r.Value)
+}
@@ -0,0 +1,56 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/igwtcode/go-env/internal/topt"
+)
+
+// validator is implemented by structs checking their own invariants once populated
+// (e.g., MinReplicas <= MaxReplicas).
+type validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*validator)(nil)).Elem()
+
+// validateStructs calls the Validate method of the struct and of its nested structs, innermost first, and
+// joins their errors. Each error is reported as a ValidationError for the struct's field path. Nested structs
+// are walked the same way Unmarshal walks them. Embedded structs are validated through the method set of the
+// struct embedding them, as Go dispatches the call: their own Validate method is only called separately when
+// the embedding struct has none, e.g., when several embedded structs make it ambiguous.
+func (p *Parser) validateStructs(v reflect.Value, prefix string, path string, st *decodeState) error {
+	return p.validateStruct(v, prefix, path, st, true)
+}
+
+// validateStruct walks a struct for validateStructs, calling its own Validate method only when self is set.
+func (p *Parser) validateStruct(v reflect.Value, prefix string, path string, st *decodeState, self bool) error {
+	var errs []error
+	t := v.Type()
+	outer := reflect.PointerTo(t).Implements(validatorType)
+	meta := p.structMeta(t, prefix)
+	for i := 0; i < v.NumField(); i++ {
+		field, fieldValue := t.Field(i), v.Field(i)
+		if !field.IsExported() || meta[i].skip || field.Type.Kind() != reflect.Struct || p.isLeafType(field.Type) {
+			continue
+		}
+		if _, isJSON := meta[i].tagOptions[topt.JSON]; isJSON {
+			continue
+		}
+		nestedSelf := !field.Anonymous || !outer
+		if err := p.validateStruct(fieldValue, p.nestedPrefix(field, meta[i].tagOptions, prefix), joinPath(path, field.Name), st, nestedSelf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if !self {
+		return errors.Join(errs...)
+	}
+	if val, ok := v.Addr().Interface().(validator); ok {
+		if err := val.Validate(); err != nil {
+			errs = append(errs, &ValidationError{Field: path, Err: err, root: st.root})
+		}
+	}
+	return errors.Join(errs...)
+}
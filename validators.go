@@ -1,9 +1,17 @@
 package env
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/igwtcode/go-env/internal/topt"
 )
@@ -20,14 +28,162 @@ var (
 
 	// AWS IAM Role ARN validation (e.g., arn:aws:iam::123456789012:role/MyRole)
 	awsRoleArnRgx = regexp.MustCompile(`^arn:aws:iam::\d{12}:role\/[a-zA-Z_+=,.@\-]{1,64}$`)
+
+	// AWS SQS queue URL validation (e.g., https://sqs.us-east-1.amazonaws.com/123456789012/my-queue.fifo)
+	awsSqsQueueURLRgx = regexp.MustCompile(`^https://(?:sqs\.([a-z0-9-]+)|([a-z0-9-]+)\.queue)\.amazonaws\.com(?:\.cn)?/(\d{12})/([a-zA-Z0-9_-]+(?:\.fifo)?)$`)
+
+	// AWS SNS topic ARN validation (e.g., arn:aws:sns:us-east-1:123456789012:my-topic.fifo)
+	awsSnsTopicArnRgx = regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:sns:([a-z0-9-]+):\d{12}:([a-zA-Z0-9_-]+(?:\.fifo)?)$`)
+
+	// AWS Lambda function validation: a name, partial ARN (123456789012:function:my-function) or full ARN,
+	// with an optional version or alias qualifier (e.g., arn:aws:lambda:us-east-1:123456789012:function:my-function:prod)
+	awsLambdaRgx = regexp.MustCompile(`^(?:(?:arn:aws(?:-cn|-us-gov)?:lambda:([a-z0-9-]+):)?\d{12}:function:)?([a-zA-Z0-9_-]{1,64})(?::(\$LATEST|[a-zA-Z0-9_-]{1,128}))?$`)
+
+	// AWS KMS key validation: a key ID (UUID or multi-Region mrk-...) or alias name, or the ARN of a key or alias
+	// (e.g., arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab or alias/my-key)
+	awsKmsKeyRgx    = regexp.MustCompile(`^(?:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32}|alias/[a-zA-Z0-9/_-]{1,250})$`)
+	awsKmsKeyArnRgx = regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:kms:([a-z0-9-]+):\d{12}:(?:key/(?:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32})|alias/[a-zA-Z0-9/_-]{1,250})$`)
+
+	// AWS ARN prefix, capturing the service (e.g., arn:aws:sqs:...)
+	awsArnServiceRgx = regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:([a-z0-9-]+):`)
 )
 
 // Validation options map for v_aws_xxx exclusive options
 var awsValidationMap = map[string]func(string) error{
-	topt.V_AWS_REGION:      vAwsRegion,
-	topt.V_AWS_ACCOUNT_ID:  vAwsAccountID,
-	topt.V_AWS_BUCKET_NAME: vAwsBucketName,
-	topt.V_AWS_ROLE_ARN:    vAwsRoleArn,
+	topt.V_AWS_REGION:        vAwsRegion,
+	topt.V_AWS_ACCOUNT_ID:    vAwsAccountID,
+	topt.V_AWS_BUCKET_NAME:   vAwsBucketName,
+	topt.V_AWS_ROLE_ARN:      vAwsRoleArn,
+	topt.V_AWS_SQS_QUEUE_URL: vAwsSqsQueueURL,
+	topt.V_AWS_SNS_TOPIC_ARN: vAwsSnsTopicArn,
+	topt.V_AWS_LAMBDA:        vAwsLambda,
+	topt.V_AWS_KMS_KEY:       vAwsKmsKey,
+}
+
+// Validation options map for general options without arguments, which can be combined with each other
+var validationMap = map[string]func(string) error{
+	topt.V_MAC:    vMac,
+	topt.V_JSON:   vJSON,
+	topt.V_BASE64: vBase64,
+	topt.ALPHANUM: vAlphanum,
+	topt.ASCII:    vASCII,
+}
+
+// regexCache holds the compiled patterns of `regex` options, so each pattern is compiled only once
+var regexCache sync.Map
+
+// vRegex checks whether the provided value matches the pattern of the `regex` option.
+//
+// Returns an error if the pattern is invalid or the validation fails.
+func vRegex(val string, pattern string) error {
+	var rgx *regexp.Regexp
+	if cached, ok := regexCache.Load(pattern); ok {
+		rgx = cached.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex pattern: %v", err)
+		}
+		regexCache.Store(pattern, compiled)
+		rgx = compiled
+	}
+
+	if !rgx.MatchString(val) {
+		return fmt.Errorf("invalid value: %v. Must match the pattern %v", val, pattern)
+	}
+	return nil
+}
+
+// vOneOf checks whether the provided value is one of the allowed values of the `oneof` option.
+//
+// Returns an error listing the allowed values if the validation fails.
+func vOneOf(val string, allowed []string) error {
+	for _, a := range allowed {
+		if val == strings.TrimSpace(a) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value: %v. Must be one of %v", val, strings.Join(allowed, ", "))
+}
+
+// vLen checks whether the provided value has exactly the number of characters of the `len` option.
+//
+// Returns an error if the option is invalid or the validation fails.
+func vLen(val string, length string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid len value: %s", length)
+	}
+	if c := utf8.RuneCountInString(val); c != n {
+		return fmt.Errorf("invalid value: %v. Must be %d characters long, got %d", val, n, c)
+	}
+	return nil
+}
+
+// vAlphanum checks whether the provided value only contains ASCII letters and digits.
+//
+// Returns an error if the validation fails.
+func vAlphanum(val string) error {
+	for _, r := range val {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Errorf("invalid value: %v. Must only contain letters and digits", val)
+		}
+	}
+	return nil
+}
+
+// vASCII checks whether the provided value only contains ASCII characters.
+//
+// Returns an error if the validation fails.
+func vASCII(val string) error {
+	for _, r := range val {
+		if r > unicode.MaxASCII {
+			return fmt.Errorf("invalid value: %v. Must only contain ASCII characters", val)
+		}
+	}
+	return nil
+}
+
+// vDatetime checks whether the provided value parses under the time layout of the `v_datetime` option.
+//
+// Returns an error if the validation fails.
+func vDatetime(val string, layout string) error {
+	if _, err := time.Parse(layout, val); err != nil {
+		return fmt.Errorf("invalid date/time: %v. Expected layout: %v", val, layout)
+	}
+	return nil
+}
+
+// vJSON checks whether the provided value is well-formed JSON.
+//
+// Returns an error if the validation fails.
+func vJSON(val string) error {
+	if !json.Valid([]byte(val)) {
+		return fmt.Errorf("invalid JSON value: %v", val)
+	}
+	return nil
+}
+
+// vBase64 checks whether the provided value decodes as standard or URL-safe base64, with or without padding.
+//
+// Returns an error if the validation fails.
+func vBase64(val string) error {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if _, err := enc.DecodeString(val); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid base64 value: %v", val)
+}
+
+// vMac checks whether the provided value is a valid MAC address (IEEE 802 MAC-48, EUI-48, EUI-64, or a 20-octet IP over InfiniBand address).
+//
+// Returns an error if the validation fails.
+func vMac(mac string) error {
+	if _, err := net.ParseMAC(mac); err != nil {
+		return fmt.Errorf("invalid MAC address: %v", mac)
+	}
+	return nil
 }
 
 // vAwsRegion checks whether the provided AWS region name is valid based on the standard format.
@@ -89,3 +245,103 @@ func vAwsRoleArn(arn string) error {
 	}
 	return nil
 }
+
+// vAwsSqsQueueURL checks whether the provided AWS SQS queue URL is valid.
+//
+// A queue URL should follow this pattern: https://sqs.region.amazonaws.com/account-id/queue-name (or the legacy
+// https://region.queue.amazonaws.com/...), where the queue name is 1-80 characters long, consisting of letters,
+// digits, hyphens and underscores. FIFO queue names end with the .fifo suffix, which counts toward the length.
+//
+// Returns an error if the validation fails.
+func vAwsSqsQueueURL(url string) error {
+	m := awsSqsQueueURLRgx.FindStringSubmatch(url)
+	if m == nil {
+		return fmt.Errorf("invalid AWS SQS queue URL: %v. Must be in the format 'https://sqs.region.amazonaws.com/account-id/queue-name'", url)
+	}
+	if region := m[1] + m[2]; !awsRegionRgx.MatchString(region) {
+		return fmt.Errorf("invalid AWS SQS queue URL: %v. Invalid region name: %v", url, region)
+	}
+	if name := m[4]; len(name) > 80 {
+		return fmt.Errorf("invalid AWS SQS queue URL: %v. Queue name must be at most 80 characters long, including the .fifo suffix", url)
+	}
+	return nil
+}
+
+// vAwsSnsTopicArn checks whether the provided AWS SNS topic ARN is valid.
+//
+// A topic ARN should follow this pattern: arn:aws:sns:region:account-id:topic-name, where the topic name is 1-256
+// characters long, consisting of letters, digits, hyphens and underscores. FIFO topic names end with the .fifo
+// suffix, which counts toward the length. ARNs of other services, and of subscriptions, are rejected.
+//
+// Returns an error if the validation fails.
+func vAwsSnsTopicArn(arn string) error {
+	if m := awsArnServiceRgx.FindStringSubmatch(arn); m != nil && m[1] != "sns" {
+		return fmt.Errorf("invalid AWS SNS topic ARN: %v. Must be an SNS ARN, not %s", arn, m[1])
+	}
+	m := awsSnsTopicArnRgx.FindStringSubmatch(arn)
+	if m == nil {
+		return fmt.Errorf("invalid AWS SNS topic ARN: %v. Must be in the format 'arn:aws:sns:region:account-id:topic-name'", arn)
+	}
+	if region := m[1]; !awsRegionRgx.MatchString(region) {
+		return fmt.Errorf("invalid AWS SNS topic ARN: %v. Invalid region name: %v", arn, region)
+	}
+	if name := m[2]; len(name) > 256 {
+		return fmt.Errorf("invalid AWS SNS topic ARN: %v. Topic name must be at most 256 characters long, including the .fifo suffix", arn)
+	}
+	return nil
+}
+
+// vAwsLambda checks whether the provided AWS Lambda function name or ARN is valid.
+//
+// The function can be given as a name (my-function), a partial ARN (account-id:function:my-function) or a full ARN
+// (arn:aws:lambda:region:account-id:function:my-function), each optionally followed by a version or alias qualifier
+// (e.g., :1, :$LATEST or :prod). Function names are 1-64 characters long, consisting of letters, digits, hyphens
+// and underscores.
+//
+// Returns an error if the validation fails.
+func vAwsLambda(fn string) error {
+	if m := awsArnServiceRgx.FindStringSubmatch(fn); m != nil && m[1] != "lambda" {
+		return fmt.Errorf("invalid AWS Lambda function: %v. Must be a Lambda ARN, not %s", fn, m[1])
+	}
+	m := awsLambdaRgx.FindStringSubmatch(fn)
+	if m == nil {
+		return fmt.Errorf("invalid AWS Lambda function: %v. Must be a function name, a partial ARN 'account-id:function:name' or an ARN 'arn:aws:lambda:region:account-id:function:name', with an optional ':qualifier'", fn)
+	}
+	if region := m[1]; region != "" && !awsRegionRgx.MatchString(region) {
+		return fmt.Errorf("invalid AWS Lambda function: %v. Invalid region name: %v", fn, region)
+	}
+	// Numeric qualifiers are versions, which start at 1
+	if qualifier := m[3]; strings.HasPrefix(qualifier, "0") && strings.Trim(qualifier, "0123456789") == "" {
+		return fmt.Errorf("invalid AWS Lambda function: %v. Version must be a positive number without leading zeros", fn)
+	}
+	return nil
+}
+
+// vAwsKmsKey checks whether the provided AWS KMS key reference is valid.
+//
+// The key can be given as a key ID (a UUID, or mrk- and 32 hex digits for multi-Region keys), an alias name
+// (alias/my-key), a key ARN (arn:aws:kms:region:account-id:key/key-id) or an alias ARN
+// (arn:aws:kms:region:account-id:alias/my-key). Alias names consist of letters, digits, slashes, hyphens and
+// underscores.
+//
+// Returns an error if the validation fails.
+func vAwsKmsKey(key string) error {
+	m := awsArnServiceRgx.FindStringSubmatch(key)
+	if m == nil {
+		if !awsKmsKeyRgx.MatchString(key) {
+			return fmt.Errorf("invalid AWS KMS key: %v. Must be a key ID, an alias name 'alias/name', or a key or alias ARN", key)
+		}
+		return nil
+	}
+	if m[1] != "kms" {
+		return fmt.Errorf("invalid AWS KMS key: %v. Must be a KMS ARN, not %s", key, m[1])
+	}
+	m = awsKmsKeyArnRgx.FindStringSubmatch(key)
+	if m == nil {
+		return fmt.Errorf("invalid AWS KMS key: %v. Must be in the format 'arn:aws:kms:region:account-id:key/key-id' or 'arn:aws:kms:region:account-id:alias/name'", key)
+	}
+	if region := m[1]; !awsRegionRgx.MatchString(region) {
+		return fmt.Errorf("invalid AWS KMS key: %v. Invalid region name: %v", key, region)
+	}
+	return nil
+}